@@ -8,32 +8,39 @@ import (
 	"os"
 	"time"
 
+	"github.com/harperreed/goflipdot/pkg/bitmap"
 	"github.com/harperreed/goflipdot/pkg/goflipdot"
 )
 
 const (
 	signAddress = 1
-	signColumns = 96
-	signRows    = 16
+	signModel   = "7000-96x16"
 )
 
 func main() {
 	serialPort := flag.String("port", "/dev/pts/7", "Serial port for the flipdot display")
 	patternNum := flag.Int("pattern", -1, "Pattern number to display (0-5), or -1 for all patterns")
+	flip := flag.Bool("flip", false, "Rotate images 180 degrees for a sign mounted upside down")
 	flag.Parse()
 
 	if *serialPort == "" {
 		log.Fatal("Serial port must be specified")
 	}
 
-	ctrl, err := goflipdot.NewController(*serialPort)
+	ctrl, err := goflipdot.NewSerialController(*serialPort)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer ctrl.Close()
 
-	if err := ctrl.AddSign("dev", signAddress, signColumns, signRows); err != nil {
+	if err := ctrl.AddSignModel("dev", signAddress, signModel); err != nil {
 		log.Fatal(err)
 	}
+	if *flip {
+		if err := ctrl.SetOrientation("dev", goflipdot.Orientation{Rotation: goflipdot.Rotate180}); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	patterns := GetPatterns()
 	patternNames := []string{
@@ -67,20 +74,23 @@ func main() {
 }
 
 func displayPattern(ctrl *goflipdot.Controller, name string, patternFunc Pattern) {
-	img := patternFunc(signColumns, signRows)
-	printArrayInfo(img, name)
-	err := ctrl.DrawImage(img, "dev")
+	canvas, err := ctrl.CreateBitmap("dev")
 	if err != nil {
+		log.Fatal(err)
+	}
+	img := patternFunc(canvas.Width, canvas.Height)
+	printArrayInfo(img, name)
+	if err := ctrl.DrawBitmap(img, "dev"); err != nil {
 		log.Printf("Failed to draw image: %v", err)
 	}
 }
 
-func printArrayInfo(img *image.Gray, name string) {
+func printArrayInfo(img image.Image, name string) {
 	fmt.Printf("\n%s:\n", name)
 	fmt.Printf("Shape: %dx%d\n", img.Bounds().Dx(), img.Bounds().Dy())
 	fmt.Print("First row: ")
 	for x := 0; x < img.Bounds().Dx(); x++ {
-		if img.GrayAt(x, 0).Y > 0 {
+		if bitmap.IsOn(img.At(x, 0)) {
 			fmt.Print("1 ")
 		} else {
 			fmt.Print("0 ")
@@ -89,7 +99,7 @@ func printArrayInfo(img *image.Gray, name string) {
 	fmt.Println()
 	fmt.Print("Last row: ")
 	for x := 0; x < img.Bounds().Dx(); x++ {
-		if img.GrayAt(x, img.Bounds().Dy()-1).Y > 0 {
+		if bitmap.IsOn(img.At(x, img.Bounds().Dy()-1)) {
 			fmt.Print("1 ")
 		} else {
 			fmt.Print("0 ")
@@ -99,7 +109,7 @@ func printArrayInfo(img *image.Gray, name string) {
 	sum := 0
 	for y := 0; y < img.Bounds().Dy(); y++ {
 		for x := 0; x < img.Bounds().Dx(); x++ {
-			if img.GrayAt(x, y).Y > 0 {
+			if bitmap.IsOn(img.At(x, y)) {
 				sum++
 			}
 		}
@@ -1,12 +1,12 @@
 package main
 
 import (
-	"image"
-	"image/color"
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+	"github.com/harperreed/goflipdot/pkg/gfx"
 )
 
 // Pattern represents a function that generates an image pattern
-type Pattern func(width, height int) *image.Gray
+type Pattern func(width, height int) *bitmap.Bitmap
 
 // GetPatterns returns a map of pattern names to their generating functions
 func GetPatterns() map[string]Pattern {
@@ -17,89 +17,57 @@ func GetPatterns() map[string]Pattern {
 		"All pixels on":       createArray4,
 		"Alternating columns": createArray5,
 		"Large 'X'":           createArray6,
-		"Clear":           	   createArray7,
+		"Clear":               createArray7,
 	}
 }
 
-func createArray1(width, height int) *image.Gray {
-	img := image.NewGray(image.Rect(0, 0, width, height))
-	for y := 0; y < height; y++ {
-		img.Set(0, y, color.White)
-		img.Set(width-1, y, color.White)
-	}
-	return img
+func createArray1(width, height int) *bitmap.Bitmap {
+	b := bitmap.New(width, height)
+	p := gfx.New(b)
+	p.Line(0, 0, 0, height-1)
+	p.Line(width-1, 0, width-1, height-1)
+	return b
 }
 
-func createArray2(width, height int) *image.Gray {
-    img := image.NewGray(image.Rect(0, 0, width, height))
-
-    // Fill the entire image with black (0)
-    for y := 0; y < height; y++ {
-        for x := 0; x < width; x++ {
-            img.Set(x, y, color.Black)
-        }
-    }
-
-    // Set the borders to white (255)
-    for x := 0; x < width; x++ {
-        img.Set(x, 0, color.White)         // Top border
-        img.Set(x, height-1, color.White)  // Bottom border
-    }
-    for y := 0; y < height; y++ {
-        img.Set(0, y, color.White)         // Left border
-        img.Set(width-1, y, color.White)   // Right border
-    }
-
-    return img
+func createArray2(width, height int) *bitmap.Bitmap {
+	b := bitmap.New(width, height)
+	gfx.New(b).Rect(b.Bounds())
+	return b
 }
 
-func createArray3(width, height int) *image.Gray {
-	img := image.NewGray(image.Rect(0, 0, width, height))
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			if (x+y)%2 == 0 {
-				img.Set(x, y, color.White)
-			}
-		}
+func createArray3(width, height int) *bitmap.Bitmap {
+	b := bitmap.New(width, height)
+	p := gfx.New(b)
+	// Diagonals two dots apart light every other dot
+	for d := 0; d < width+height; d += 2 {
+		p.Line(d, 0, d-height+1, height-1)
 	}
-	return img
+	return b
 }
 
-func createArray4(width, height int) *image.Gray {
-	img := image.NewGray(image.Rect(0, 0, width, height))
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			img.Set(x, y, color.White)
-		}
-	}
-	return img
+func createArray4(width, height int) *bitmap.Bitmap {
+	b := bitmap.New(width, height)
+	gfx.New(b).FillRect(b.Bounds())
+	return b
 }
 
-func createArray5(width, height int) *image.Gray {
-	img := image.NewGray(image.Rect(0, 0, width, height))
-	for y := 0; y < height; y++ {
-		for x := 1; x < width; x += 2 {
-			img.Set(x, y, color.White)
-		}
+func createArray5(width, height int) *bitmap.Bitmap {
+	b := bitmap.New(width, height)
+	p := gfx.New(b)
+	for x := 1; x < width; x += 2 {
+		p.Line(x, 0, x, height-1)
 	}
-	return img
+	return b
 }
 
-func createArray6(width, height int) *image.Gray {
-	img := image.NewGray(image.Rect(0, 0, width, height))
-	for i := 0; i < height; i++ {
-		img.Set(i*6, i, color.White)
-		img.Set(width-1-i*6, i, color.White)
-	}
-	return img
+func createArray6(width, height int) *bitmap.Bitmap {
+	b := bitmap.New(width, height)
+	p := gfx.New(b)
+	p.Line(0, 0, width-1, height-1)
+	p.Line(width-1, 0, 0, height-1)
+	return b
 }
 
-func createArray7(width, height int) *image.Gray {
-	img := image.NewGray(image.Rect(0, 0, width, height))
-	for y := 0; y < height; y++ {
-		for x := 1; x < width; x += 2 {
-			img.Set(x, y, color.Black)
-		}
-	}
-	return img
+func createArray7(width, height int) *bitmap.Bitmap {
+	return bitmap.New(width, height)
 }
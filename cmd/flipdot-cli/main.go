@@ -1,22 +1,57 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"image/gif"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
 	"time"
 
-	"github.com/tarm/serial"
 	"github.com/harperreed/goflipdot/internal"
+	"github.com/harperreed/goflipdot/pkg/goflipdot"
+	"github.com/harperreed/goflipdot/pkg/imageconv"
+	"github.com/harperreed/goflipdot/pkg/metrics"
+	"github.com/tarm/serial"
 )
 
+var ditherModes = map[string]imageconv.Dither{
+	"threshold": imageconv.DitherThreshold,
+	"floyd":     imageconv.DitherFloydSteinberg,
+	"atkinson":  imageconv.DitherAtkinson,
+	"bayer":     imageconv.DitherBayer,
+}
+
 func main() {
 	portName := flag.String("port", "/dev/ttyUSB0", "Serial port name")
-	command := flag.String("cmd", "", "Command to send (start_test, stop_test, draw_pattern, or send_byte)")
+	command := flag.String("cmd", "", "Command to send (start_test, stop_test, draw_pattern, send_byte or play_gif)")
 	byteToSend := flag.Int("byte", 0xFF, "Byte to send when using send_byte command")
+	gifPath := flag.String("gif", "", "Animated GIF to play when using play_gif command")
+	address := flag.Int("addr", 1, "Sign address for play_gif")
+	width := flag.Int("width", 86, "Sign width for play_gif")
+	height := flag.Int("height", 7, "Sign height for play_gif")
+	fps := flag.Float64("fps", 2, "Maximum frames per second for play_gif")
+	dither := flag.String("dither", "threshold", "Dithering for play_gif (threshold, floyd, atkinson or bayer)")
+	metricsAddr := flag.String("metrics", "", "Address to serve Prometheus metrics on during play_gif, e.g. localhost:9100")
 	verbose := flag.Bool("v", false, "Verbose mode")
 	flag.Parse()
 
+	if *command == "play_gif" {
+		mode, ok := ditherModes[*dither]
+		if !ok {
+			log.Fatalf("Unknown dither mode: %s", *dither)
+		}
+		if err := playGIF(*portName, *gifPath, *address, *width, *height, *fps, mode, *verbose, *metricsAddr); err != nil {
+			log.Fatalf("Failed to play GIF: %v", err)
+		}
+		return
+	}
+
 	config := &serial.Config{
 		Name:        *portName,
 		Baud:        internal.BaudRate,
@@ -45,7 +80,7 @@ func main() {
 		packet = internal.FormatPacket(internal.CommandCodes["stop_test_signs"], '0', nil)
 	case "draw_pattern":
 		imageData := []byte{0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA}
-		payload := append([]byte{byte(len(imageData)), 0}, internal.ToAsciiHex(imageData)...)
+		payload := append(internal.ToAsciiHex([]byte{byte(len(imageData))}), internal.ToAsciiHex(imageData)...)
 		packet = internal.FormatPacket(internal.CommandCodes["write_image"], '1', payload)
 	case "send_byte":
 		packet = []byte{byte(*byteToSend)}
@@ -82,3 +117,50 @@ func main() {
 
 	fmt.Println("Command completed")
 }
+
+// playGIF plays an animated GIF on one sign until it finishes or the user
+// interrupts it
+func playGIF(portName, path string, address, width, height int, fps float64, dither imageconv.Dither, verbose bool, metricsAddr string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	g, err := gif.DecodeAll(file)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	opts := []goflipdot.Option{goflipdot.WithMaxFPS(fps)}
+	if verbose {
+		handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+		opts = append(opts, goflipdot.WithLogger(slog.New(handler)))
+	}
+	if metricsAddr != "" {
+		collector := metrics.New()
+		opts = append(opts, goflipdot.WithMetrics(collector))
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", collector)
+		go func() {
+			if err := http.ListenAndServe(metricsAddr, mux); err != nil {
+				log.Printf("Metrics server stopped: %v", err)
+			}
+		}()
+	}
+	ctrl, err := goflipdot.NewSerialController(portName, opts...)
+	if err != nil {
+		return err
+	}
+	defer ctrl.Close()
+	if err := ctrl.AddSign("gif", address, width, height, false); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err = ctrl.PlayGIF(ctx, g, "gif", imageconv.Options{Dither: dither})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
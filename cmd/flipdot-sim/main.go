@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+	"github.com/harperreed/goflipdot/pkg/emulator"
+)
+
+const (
+	dotOn  = "●"
+	dotOff = "○"
+)
+
+func main() {
+	rows := flag.Int("rows", 16, "Number of rows on each emulated sign")
+	flag.Parse()
+
+	emu := emulator.New(*rows)
+	pty, err := emu.ServePTY()
+	if err != nil {
+		log.Fatalf("Failed to open pty: %v", err)
+	}
+	defer pty.Close()
+
+	var mu sync.Mutex
+	redraw := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		render(emu, pty.Name)
+	}
+	emu.OnUpdate(func(address int) { redraw() })
+	emu.OnError(func(err error) { log.Printf("Rejected frame: %v", err) })
+	redraw()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+	fmt.Println()
+}
+
+func render(emu *emulator.Emulator, portName string) {
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+	fmt.Fprintf(&b, "flipdot-sim listening on %s (Ctrl-C to quit)\n", portName)
+	if emu.TestMode() {
+		b.WriteString("Test mode running\n")
+	}
+
+	addresses := emu.Addresses()
+	if len(addresses) == 0 {
+		b.WriteString("\nWaiting for images...\n")
+	}
+	for _, address := range addresses {
+		img, ok := emu.Image(address)
+		if !ok {
+			continue
+		}
+		bounds := img.Bounds()
+		fmt.Fprintf(&b, "\nSign %d (%dx%d):\n", address, bounds.Dx(), bounds.Dy())
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if img.GrayAt(x, y).Y >= bitmap.Threshold {
+					b.WriteString(dotOn)
+				} else {
+					b.WriteString(dotOff)
+				}
+			}
+			b.WriteByte('\n')
+		}
+	}
+	os.Stdout.WriteString(b.String())
+}
@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/harperreed/goflipdot/internal"
+	"github.com/harperreed/goflipdot/internal/transport"
+)
+
+var (
+	ErrClosed = errors.New("controller is closed")
+)
+
+// busRequest is a frame queued for the bus writer
+type busRequest struct {
+	ctx           context.Context
+	frame         []byte
+	awaitResponse bool
+	// response is filled in before done is signalled
+	response *Response
+	done     chan error
+}
+
+// writeDeadliner is implemented by transports such as net.Conn and
+// pollable files whose writes can be bounded by a deadline
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// send queues frame for the bus writer and waits for it to be written and,
+// if awaitResponse is set, for the response. If ctx ends first, send returns
+// ctx.Err() without waiting for the bus.
+func (c *HanoverController) send(ctx context.Context, frame []byte, awaitResponse bool) (Response, error) {
+	req := busRequest{
+		ctx:           ctx,
+		frame:         frame,
+		awaitResponse: awaitResponse,
+		response:      new(Response),
+		done:          make(chan error, 1),
+	}
+	select {
+	case c.requests <- req:
+	case <-c.closed:
+		return Response{}, ErrClosed
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	}
+	select {
+	case err := <-req.done:
+		return *req.response, err
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	}
+}
+
+// runBus is the only goroutine that writes to the port, so frames from
+// concurrent callers are never interleaved on the wire
+func (c *HanoverController) runBus() {
+	defer close(c.busDone)
+	for {
+		select {
+		case req := <-c.requests:
+			req.done <- c.transmit(req)
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+func (c *HanoverController) transmit(req busRequest) error {
+	// The caller may have given up while the request was queued
+	if err := req.ctx.Err(); err != nil {
+		return err
+	}
+	port, conn := c.currentPort()
+	if port == nil {
+		return ErrDisconnected
+	}
+	if req.awaitResponse {
+		c.discardResponses()
+	}
+
+	deadlineSet := false
+	if wd, ok := port.(writeDeadliner); ok {
+		deadline, _ := req.ctx.Deadline()
+		if err := wd.SetWriteDeadline(deadline); err == nil {
+			deadlineSet = !deadline.IsZero()
+			defer wd.SetWriteDeadline(time.Time{})
+		}
+	}
+
+	// A frame cut short on an earlier connection died with it
+	if c.partialConn != conn {
+		c.partial = nil
+	}
+	data := req.frame
+	if c.partial != nil {
+		// Finish the frame cut short last time so the signs do not read it
+		// as the start of this one
+		data = append(rejectTail(c.partial), data...)
+	}
+
+	c.logFrames(req.ctx, "Sending packet", req.frame)
+	start := time.Now()
+	n, err := port.Write(data)
+	if err == nil && n != len(data) {
+		err = fmt.Errorf("incomplete write: wrote %d bytes out of %d", n, len(data))
+	}
+	if n > 0 || err == nil {
+		c.partial = openFrame(append(c.partial, data[:n]...))
+		c.partialConn = conn
+	}
+	if err != nil {
+		// The caller's deadline cut the write short; the transport itself is
+		// fine, so keep it for everyone else
+		if deadlineSet && errors.Is(err, os.ErrDeadlineExceeded) {
+			return context.DeadlineExceeded
+		}
+		c.handleWriteFailure(conn, err)
+		return fmt.Errorf("failed to write packet: %w", err)
+	}
+	c.metrics.Write(n, time.Since(start))
+	c.logger.LogAttrs(req.ctx, slog.LevelDebug, "Wrote packet", slog.Int("length", n))
+
+	if req.awaitResponse {
+		resp, err := c.awaitResponse(req.ctx, req.frame[1])
+		*req.response = resp
+		return err
+	}
+	return nil
+}
+
+// runReader is the only goroutine that reads from port. It runs until the
+// port returns an error, which includes the port being closed.
+func (c *HanoverController) runReader(port transport.Transport) {
+	for {
+		buf := make([]byte, 128)
+		n, err := port.Read(buf)
+		if n > 0 {
+			select {
+			case c.responses <- buf[:n]:
+			case <-c.closed:
+				return
+			}
+		}
+		if err != nil {
+			select {
+			case <-c.closed:
+			default:
+				c.logger.Warn("Stopped reading responses", "error", err)
+			}
+			return
+		}
+	}
+}
+
+// discardResponses drops anything received since the last command so it is
+// not mistaken for the response to the next one
+func (c *HanoverController) discardResponses() {
+	for {
+		select {
+		case <-c.responses:
+		default:
+			return
+		}
+	}
+}
+
+// openFrame returns the frame left unfinished at the end of the bytes
+// written to the bus, or nil if the last frame is complete
+func openFrame(wire []byte) []byte {
+	start := bytes.LastIndexByte(wire, internal.StartByte)
+	if start < 0 {
+		return nil
+	}
+	f := wire[start:]
+	if end := bytes.IndexByte(f, internal.EndByte); end >= 0 && len(f) >= end+3 {
+		return nil
+	}
+	return append([]byte(nil), f...)
+}
+
+// rejectTail returns the bytes that complete the unfinished frame f with a
+// checksum that cannot match, so the signs reject the frame whole like any
+// frame corrupted on the line rather than merge it with the next one
+func rejectTail(f []byte) []byte {
+	wrong := func(body []byte) []byte {
+		return internal.ToAsciiHex([]byte{^internal.Checksum(body)})
+	}
+	end := bytes.IndexByte(f, internal.EndByte)
+	switch {
+	case end < 0:
+		body := append(append([]byte(nil), f...), internal.EndByte)
+		return append([]byte{internal.EndByte}, wrong(body)...)
+	case len(f) == end+1:
+		return wrong(f)
+	}
+	// One checksum digit is out; a second that differs from the correct
+	// one spoils it
+	right := internal.ToAsciiHex([]byte{internal.Checksum(f[:end+1])})
+	if f[end+1] != right[0] || right[1] != '0' {
+		return []byte{'0'}
+	}
+	return []byte{'1'}
+}
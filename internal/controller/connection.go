@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"errors"
+	"time"
+
+	"github.com/harperreed/goflipdot/internal/transport"
+)
+
+const (
+	DefaultMinBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+)
+
+var (
+	ErrDisconnected = errors.New("transport disconnected")
+)
+
+// ConnectionState describes whether the controller has a working transport
+type ConnectionState int
+
+const (
+	StateConnected ConnectionState = iota
+	StateDisconnected
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	}
+	return "unknown"
+}
+
+// WithReopen enables automatic reconnection. When a write fails the
+// transport is closed and open is retried with exponential backoff until it
+// succeeds or the controller is closed. Writes made while disconnected fail
+// with ErrDisconnected.
+func WithReopen(open func() (transport.Transport, error)) Option {
+	return func(c *HanoverController) {
+		c.open = open
+	}
+}
+
+// WithBackoff sets the delay before the first reconnection attempt and the
+// limit it doubles up to on each failed attempt
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *HanoverController) {
+		c.minBackoff = min
+		c.maxBackoff = max
+	}
+}
+
+// WithStateHandler registers fn to be called whenever the connection state
+// changes. err is the write error that caused a disconnection, or nil.
+func WithStateHandler(fn func(state ConnectionState, err error)) Option {
+	return func(c *HanoverController) {
+		c.onState = fn
+	}
+}
+
+// State returns the current connection state
+func (c *HanoverController) State() ConnectionState {
+	c.portMu.Lock()
+	defer c.portMu.Unlock()
+	return c.state
+}
+
+// currentPort returns the port to write to, or nil while disconnected, and
+// the connection it belongs to
+func (c *HanoverController) currentPort() (transport.Transport, uint64) {
+	c.portMu.Lock()
+	defer c.portMu.Unlock()
+	if c.state != StateConnected {
+		return nil, c.conn
+	}
+	return c.port, c.conn
+}
+
+// handleWriteFailure drops the port of connection conn after it failed a
+// write and starts reconnecting. Without a reopen function the port is kept
+// as is.
+func (c *HanoverController) handleWriteFailure(conn uint64, err error) {
+	if c.open == nil {
+		return
+	}
+	c.portMu.Lock()
+	port := c.port
+	if c.conn != conn || c.state != StateConnected {
+		c.portMu.Unlock()
+		return
+	}
+	select {
+	case <-c.closed:
+		c.portMu.Unlock()
+		return
+	default:
+	}
+	c.state = StateDisconnected
+	port.Close()
+	c.portMu.Unlock()
+
+	c.logger.Warn("Transport disconnected", "error", err)
+	c.notifyState(StateDisconnected, err)
+	go c.reconnect()
+}
+
+func (c *HanoverController) reconnect() {
+	delay := c.minBackoff
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-c.closed:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		port, err := c.open()
+		if err != nil {
+			c.logger.Warn("Failed to reopen transport", "error", err, "retry", delay)
+			delay *= 2
+			if delay > c.maxBackoff {
+				delay = c.maxBackoff
+			}
+			continue
+		}
+
+		c.portMu.Lock()
+		select {
+		case <-c.closed:
+			c.portMu.Unlock()
+			port.Close()
+			return
+		default:
+		}
+		c.port = port
+		c.conn++
+		c.state = StateConnected
+		c.portMu.Unlock()
+
+		if c.responseTimeout > 0 {
+			go c.runReader(port)
+		}
+		// The signs may have lost power along with the link, so resend
+		// everything rather than trusting the frames sent before
+		c.forgetFrames()
+		c.logger.Info("Transport reconnected")
+		c.notifyState(StateConnected, nil)
+		return
+	}
+}
+
+func (c *HanoverController) notifyState(state ConnectionState, err error) {
+	if c.onState != nil {
+		c.onState(state, err)
+	}
+}
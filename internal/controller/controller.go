@@ -1,155 +1,400 @@
 package controller
 
 import (
-	"encoding/hex"
+	"context"
 	"errors"
 	"fmt"
 	"image"
-	"io"
-	"log"
+	"log/slog"
+	"sync"
 	"time"
-	"github.com/tarm/serial"
+
 	"github.com/harperreed/goflipdot/internal/packet"
 	"github.com/harperreed/goflipdot/internal/sign"
+	"github.com/harperreed/goflipdot/internal/transport"
+	"github.com/harperreed/goflipdot/pkg/bitmap"
 )
 
+// DefaultResponseTimeout is how long serial controllers wait for a sign response
+const DefaultResponseTimeout = 2 * time.Second
+
 var (
 	ErrSignAlreadyExists = errors.New("sign with this name already exists")
 	ErrSignNotFound      = errors.New("sign not found")
 	ErrInvalidImage      = errors.New("invalid image for sign")
 )
 
-// HanoverController controls one or more Hanover signs
+// HanoverController controls one or more Hanover signs. It is safe for
+// concurrent use; frames are written to the bus one at a time by a single
+// goroutine in the order they are submitted.
 type HanoverController struct {
-	port  io.ReadWriter
-	signs map[string]*sign.HanoverSign
+	responseTimeout time.Duration
+	open            func() (transport.Transport, error)
+	minBackoff      time.Duration
+	maxBackoff      time.Duration
+	onState         func(state ConnectionState, err error)
+	responsePolicy  ResponsePolicy
+	maxFPS          float64
+	logger          *slog.Logger
+	metrics         Metrics
+
+	portMu sync.Mutex
+	port   transport.Transport
+	state  ConnectionState
+	// conn counts reconnections, telling writes on old ports from new ones
+	conn uint64
+
+	mu         sync.RWMutex
+	signs      map[string]*sign.HanoverSign
+	schedulers map[*sign.HanoverSign]*frameScheduler
+	displays   map[string]*VirtualDisplay
+
+	// partial is the frame a cut-short write left unfinished on connection
+	// partialConn. Only the bus goroutine uses them.
+	partial     []byte
+	partialConn uint64
+
+	requests  chan busRequest
+	responses chan []byte
+	closed    chan struct{}
+	busDone   chan struct{}
+	closeOnce sync.Once
 }
 
-// NewHanoverController creates a new HanoverController
-func NewHanoverController(serialPort string) (*HanoverController, error) {
-	c := &serial.Config{Name: serialPort, Baud: 4800}
-	port, err := serial.OpenPort(c)
+// Option configures a HanoverController
+type Option func(*HanoverController)
+
+// WithResponseTimeout sets how long commands wait for a response from the
+// signs. A zero duration disables reading responses.
+func WithResponseTimeout(d time.Duration) Option {
+	return func(c *HanoverController) {
+		c.responseTimeout = d
+	}
+}
+
+// WithMaxFPS limits how many frames per second are sent to each sign. Frames
+// drawn faster than this are coalesced so only the latest is sent. Zero, the
+// default, sends every frame immediately. SetMaxFPS overrides it per sign.
+func WithMaxFPS(fps float64) Option {
+	return func(c *HanoverController) {
+		c.maxFPS = fps
+	}
+}
+
+// NewHanoverController creates a new HanoverController communicating over port
+func NewHanoverController(port transport.Transport, opts ...Option) (*HanoverController, error) {
+	if port == nil {
+		return nil, transport.ErrNilTransport
+	}
+	c := &HanoverController{
+		port:       port,
+		state:      StateConnected,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+		logger:     discardLogger,
+		metrics:    nopMetrics{},
+		signs:      make(map[string]*sign.HanoverSign),
+		schedulers: make(map[*sign.HanoverSign]*frameScheduler),
+		displays:   make(map[string]*VirtualDisplay),
+		requests:   make(chan busRequest),
+		closed:     make(chan struct{}),
+		busDone:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	go c.runBus()
+	if c.responseTimeout > 0 {
+		c.responses = make(chan []byte, 16)
+		go c.runReader(port)
+	}
+	return c, nil
+}
+
+// NewSerialHanoverController opens serialPort and creates a HanoverController on it.
+// Responses are read for DefaultResponseTimeout and the port is reopened
+// after write failures, unless overridden by opts.
+func NewSerialHanoverController(serialPort string, opts ...Option) (*HanoverController, error) {
+	port, err := transport.OpenSerial(serialPort)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open serial port: %w", err)
+		return nil, err
 	}
-	return &HanoverController{
-		port:  port,
-		signs: make(map[string]*sign.HanoverSign),
-	}, nil
+	opts = append([]Option{
+		WithResponseTimeout(DefaultResponseTimeout),
+		WithReopen(func() (transport.Transport, error) {
+			return transport.OpenSerial(serialPort)
+		}),
+	}, opts...)
+	return NewHanoverController(port, opts...)
+}
+
+// Close stops the bus writer and any reconnection attempts and closes the
+// port. Calls made after Close return ErrClosed.
+func (c *HanoverController) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		// Closing the port unblocks a bus writer stuck in Write
+		c.portMu.Lock()
+		if c.state == StateConnected {
+			err = c.port.Close()
+		}
+		c.portMu.Unlock()
+		<-c.busDone
+	})
+	return err
 }
 
 // AddSign adds a sign for the controller to communicate with
 func (c *HanoverController) AddSign(name string, sign *sign.HanoverSign) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if _, exists := c.signs[name]; exists {
-		return errors.New("sign with this name already exists")
+		return ErrSignAlreadyExists
 	}
 	c.signs[name] = sign
+	c.schedulers[sign] = newFrameScheduler(c.maxFPS, func() {
+		c.metrics.FrameDropped(name)
+	})
 	return nil
 }
 
+// SetMaxFPS limits how many frames per second are sent to the named sign.
+// Zero removes the limit.
+func (c *HanoverController) SetMaxFPS(name string, fps float64) error {
+	if fps < 0 {
+		return fmt.Errorf("invalid frame rate %v", fps)
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, err := c.getSign(name)
+	if err != nil {
+		return err
+	}
+	c.schedulers[s].setMaxFPS(fps)
+	return nil
+}
+
+// DroppedFrames returns how many frames drawn to the named sign were
+// replaced by a newer frame before they could be sent
+func (c *HanoverController) DroppedFrames(name string) (uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, err := c.getSign(name)
+	if err != nil {
+		return 0, err
+	}
+	return c.schedulers[s].droppedFrames(), nil
+}
+
+// SetOrientation sets how the named sign is mounted
+func (c *HanoverController) SetOrientation(name string, o sign.Orientation) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, err := c.getSign(name)
+	if err != nil {
+		return err
+	}
+	s.Orientation = o
+	c.schedulers[s].forget()
+	return nil
+}
 
 // StartTestSigns broadcasts the test signs start command
 func (c *HanoverController) StartTestSigns() error {
-	return c.writeAndRead(packet.TestSignsStartPacket{})
+	return c.StartTestSignsContext(context.Background())
+}
+
+// StartTestSignsContext broadcasts the test signs start command, giving up
+// when ctx ends
+func (c *HanoverController) StartTestSignsContext(ctx context.Context) error {
+	_, err := c.StartTestSignsResponse(ctx)
+	return err
+}
+
+// StartTestSignsResponse broadcasts the test signs start command and returns
+// the signs' response
+func (c *HanoverController) StartTestSignsResponse(ctx context.Context) (Response, error) {
+	resp, err := c.writeAndRead(ctx, packet.TestSignsStartPacket{})
+	// The test pattern replaces whatever the signs showed, so the next frame
+	// drawn to each must be sent even if it matches the last one
+	c.forgetFrames()
+	return resp, err
 }
 
 // StopTestSigns broadcasts the test signs stop command
 func (c *HanoverController) StopTestSigns() error {
-	return c.writeAndRead(packet.TestSignsStopPacket{})
+	return c.StopTestSignsContext(context.Background())
 }
 
-func (c *HanoverController) DrawImage(img *image.Gray, signName string) error {
-    sign, ok := c.signs[signName]
-    if !ok {
-        return ErrSignNotFound
-    }
+// StopTestSignsContext broadcasts the test signs stop command, giving up
+// when ctx ends
+func (c *HanoverController) StopTestSignsContext(ctx context.Context) error {
+	_, err := c.StopTestSignsResponse(ctx)
+	return err
+}
 
-    if err := sign.ValidateImage(img); err != nil {
-        return fmt.Errorf("invalid image: %w", err)
-    }
+// StopTestSignsResponse broadcasts the test signs stop command and returns
+// the signs' response
+func (c *HanoverController) StopTestSignsResponse(ctx context.Context) (Response, error) {
+	resp, err := c.writeAndRead(ctx, packet.TestSignsStopPacket{})
+	// What the signs show after the test pattern is unknown
+	c.forgetFrames()
+	return resp, err
+}
 
-    pkt := packet.ImagePacket{
-        Address: sign.Address,
-        Image:   img,
-    }
+// DrawOption changes how a single frame is drawn
+type DrawOption func(*drawConfig)
 
-    bytes, err := pkt.GetBytes()
-    if err != nil {
-        return fmt.Errorf("failed to get packet bytes: %w", err)
-    }
+type drawConfig struct {
+	force bool
+}
 
-    _, err = c.port.Write(bytes)
-    if err != nil {
-        return fmt.Errorf("failed to write packet: %w", err)
-    }
+// Force sends the frame even if the sign already shows it, for example after
+// the sign has been power cycled
+func Force() DrawOption {
+	return func(d *drawConfig) {
+		d.force = true
+	}
+}
 
-    return nil
+// DrawImage sends an image to the named sign
+func (c *HanoverController) DrawImage(img *image.Gray, signName string, opts ...DrawOption) error {
+	return c.DrawImageContext(context.Background(), img, signName, opts...)
 }
 
-// GetSign returns a sign by name
-func (c *HanoverController) GetSign(name string) (*sign.HanoverSign, error) {
-	return c.getSign(name)
+// DrawImageContext sends an image to the named sign, giving up when ctx ends.
+// Frames identical to the one the sign already shows are skipped unless
+// Force is given. If the sign has a frame rate limit the call waits for the
+// sign's next slot; when a newer frame is drawn in the meantime this frame is
+// dropped and the call returns nil.
+func (c *HanoverController) DrawImageContext(ctx context.Context, img *image.Gray, signName string, opts ...DrawOption) error {
+	return c.draw(ctx, signName, opts, func(s *sign.HanoverSign) (*frame, error) {
+		if err := s.ValidateImage(img); err != nil {
+			return nil, fmt.Errorf("invalid image: %w", err)
+		}
+		return encodeFrame(packet.ImagePacket{
+			Address: s.Address,
+			Image:   s.OrientImage(img),
+		}, bitmap.FromImage(img))
+	})
 }
 
-func (c *HanoverController) getSign(name string) (*sign.HanoverSign, error) {
-	if name == "" && len(c.signs) == 1 {
-		for _, s := range c.signs {
-			return s, nil
+// DrawBitmap sends a bitmap to the named sign
+func (c *HanoverController) DrawBitmap(b *bitmap.Bitmap, signName string, opts ...DrawOption) error {
+	return c.DrawBitmapContext(context.Background(), b, signName, opts...)
+}
+
+// DrawBitmapContext sends a bitmap to the named sign, giving up when ctx
+// ends. It behaves like DrawImageContext.
+func (c *HanoverController) DrawBitmapContext(ctx context.Context, b *bitmap.Bitmap, signName string, opts ...DrawOption) error {
+	return c.draw(ctx, signName, opts, func(s *sign.HanoverSign) (*frame, error) {
+		if err := s.ValidateBitmap(b); err != nil {
+			return nil, fmt.Errorf("invalid image: %w", err)
 		}
+		return encodeFrame(packet.ImagePacket{
+			Address: s.Address,
+			Dots:    s.OrientBitmap(b),
+		}, b.Clone())
+	})
+}
+
+// draw sends the frame encode builds for a snapshot of the named sign
+func (c *HanoverController) draw(ctx context.Context, signName string, opts []DrawOption, encode func(*sign.HanoverSign) (*frame, error)) error {
+	var cfg drawConfig
+	for _, opt := range opts {
+		opt(&cfg)
 	}
-	if s, ok := c.signs[name]; ok {
-		return s, nil
+	select {
+	case <-c.closed:
+		return ErrClosed
+	default:
 	}
-	return nil, fmt.Errorf("%w: %s", ErrSignNotFound, name)
-}
 
-func (c *HanoverController) write(pkt packet.Packet) error {
-	bytes, err := pkt.GetBytes()
+	c.mu.RLock()
+	name, s, err := c.lookupSign(signName)
 	if err != nil {
-		return fmt.Errorf("failed to get packet bytes: %w", err)
+		c.mu.RUnlock()
+		return err
 	}
-	log.Printf("Sending packet: %s", hex.EncodeToString(bytes))
-	n, err := c.port.Write(bytes)
+	sign, scheduler := *s, c.schedulers[s]
+	c.mu.RUnlock()
+
+	f, err := encode(&sign)
 	if err != nil {
-		return fmt.Errorf("failed to write packet: %w", err)
+		return err
 	}
-	if n != len(bytes) {
-		return fmt.Errorf("incomplete write: wrote %d bytes out of %d", n, len(bytes))
+	if send, err := scheduler.wait(ctx, f, cfg.force); !send {
+		return err
+	}
+	if _, err := c.send(ctx, f.data, false); err != nil {
+		scheduler.failed(f)
+		return err
 	}
-	log.Printf("Wrote %d bytes to serial port", n)
+	c.metrics.FrameSent(name)
 	return nil
 }
 
-func (c *HanoverController) writeAndRead(pkt packet.Packet) error {
-	if err := c.write(pkt); err != nil {
-		return err
+// encodeFrame builds the frame for pkt, which was made from dots
+func encodeFrame(pkt packet.ImagePacket, dots *bitmap.Bitmap) (*frame, error) {
+	data, err := pkt.GetBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get packet bytes: %w", err)
 	}
+	return &frame{data: data, dots: dots}, nil
+}
 
-	// Read response with timeout
-	buf := make([]byte, 128)
-	readChan := make(chan readResult)
-	go func() {
-		n, err := c.port.Read(buf)
-		readChan <- readResult{n: n, err: err}
-	}()
+// forgetFrames discards the frames every sign is known to show, so the next
+// frame drawn to each is always sent
+func (c *HanoverController) forgetFrames() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, scheduler := range c.schedulers {
+		scheduler.forget()
+	}
+}
 
-	select {
-	case result := <-readChan:
-		if result.err != nil && !errors.Is(result.err, io.EOF) {
-			log.Printf("Failed to read response: %v", result.err)
-		} else if result.n > 0 {
-			log.Printf("Received response: %s", hex.EncodeToString(buf[:result.n]))
-		} else {
-			log.Println("No data received from read operation")
-		}
-	case <-time.After(2 * time.Second):
-		log.Println("Read operation timed out after 2 seconds")
+// GetSign returns a copy of the named sign
+func (c *HanoverController) GetSign(name string) (*sign.HanoverSign, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, err := c.getSign(name)
+	if err != nil {
+		return nil, err
 	}
+	snapshot := *s
+	return &snapshot, nil
+}
 
-	return nil
+// getSign looks up a sign by name. The caller must hold c.mu.
+func (c *HanoverController) getSign(name string) (*sign.HanoverSign, error) {
+	_, s, err := c.lookupSign(name)
+	return s, err
 }
 
-type readResult struct {
-	n   int
-	err error
+// lookupSign is getSign that also returns the name the sign was added under,
+// which differs from name when "" selects the only sign
+func (c *HanoverController) lookupSign(name string) (string, *sign.HanoverSign, error) {
+	if name == "" && len(c.signs) == 1 {
+		for n, s := range c.signs {
+			return n, s, nil
+		}
+	}
+	if s, ok := c.signs[name]; ok {
+		return name, s, nil
+	}
+	return "", nil, fmt.Errorf("%w: %s", ErrSignNotFound, name)
+}
+
+// writeAndRead sends pkt and, if responses are read, waits for the response.
+// Without a response timeout the Response is always ResponseNone.
+func (c *HanoverController) writeAndRead(ctx context.Context, pkt packet.Packet) (Response, error) {
+	bytes, err := pkt.GetBytes()
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to get packet bytes: %w", err)
+	}
+	return c.send(ctx, bytes, c.responseTimeout > 0)
 }
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+)
+
+// FrameDiff lists the dots that would flip if a frame were drawn. Points are
+// relative to the top-left corner of the image passed to DrawImage.
+type FrameDiff struct {
+	Set     []image.Point
+	Cleared []image.Point
+}
+
+// Changed returns how many dots would flip
+func (d FrameDiff) Changed() int {
+	return len(d.Set) + len(d.Cleared)
+}
+
+// Diff compares img with the last frame sent to the named sign. If no frame
+// has been sent, or the last one failed, the sign is assumed to be blank.
+func (c *HanoverController) Diff(img *image.Gray, signName string) (FrameDiff, error) {
+	c.mu.RLock()
+	s, err := c.getSign(signName)
+	if err != nil {
+		c.mu.RUnlock()
+		return FrameDiff{}, err
+	}
+	sign, scheduler := *s, c.schedulers[s]
+	c.mu.RUnlock()
+
+	if err := sign.ValidateImage(img); err != nil {
+		return FrameDiff{}, fmt.Errorf("invalid image: %w", err)
+	}
+	return diffImages(scheduler.shownDots(), img), nil
+}
+
+// diffImages compares the dots shown with an image the same size. A nil from
+// is treated as blank.
+func diffImages(from *bitmap.Bitmap, to *image.Gray) FrameDiff {
+	var d FrameDiff
+	bounds := to.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			on := to.GrayAt(x, y).Y >= bitmap.Threshold
+			p := image.Pt(x-bounds.Min.X, y-bounds.Min.Y)
+			was := from != nil && from.Get(p.X, p.Y)
+			switch {
+			case on && !was:
+				d.Set = append(d.Set, p)
+			case !on && was:
+				d.Cleared = append(d.Cleared, p)
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/harperreed/goflipdot/internal/packet"
+)
+
+// WithLogger sends the controller's log output to l. Packets and responses
+// are logged at Debug level and connection problems at Warn. By default
+// nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *HanoverController) {
+		if l == nil {
+			l = discardLogger
+		}
+		c.logger = l
+	}
+}
+
+// discardLogger drops every record without formatting it
+var discardLogger = slog.New(discardHandler{})
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// logFrames logs each frame in data at Debug level
+func (c *HanoverController) logFrames(ctx context.Context, msg string, data []byte) {
+	if !c.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	for len(data) > 0 {
+		advance, token, _ := packet.SplitFrames(data, true)
+		if token == nil {
+			break
+		}
+		data = data[advance:]
+		attrs := []slog.Attr{slog.Int("length", len(token))}
+		if f, err := packet.ParseFrame(token); err == nil {
+			attrs = append(attrs,
+				slog.Int("address", f.Address),
+				slog.String("command", string(f.Command)),
+				slog.String("checksum", string(token[len(token)-2:])),
+			)
+		} else {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		c.logger.LogAttrs(ctx, slog.LevelDebug, msg, attrs...)
+	}
+}
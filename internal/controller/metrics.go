@@ -0,0 +1,38 @@
+package controller
+
+import "time"
+
+// Metrics receives events from a controller, for example to export them to
+// a monitoring system. Methods are called from the goroutines doing the work
+// and must be safe for concurrent use.
+type Metrics interface {
+	// FrameSent is called for each frame written to the named sign
+	FrameSent(sign string)
+	// FrameDropped is called for each frame drawn to the named sign that was
+	// replaced by a newer one before it could be sent
+	FrameDropped(sign string)
+	// Write is called after each successful write to the transport
+	Write(bytes int, latency time.Duration)
+	// ReadTimeout is called when no complete response arrives in time
+	ReadTimeout()
+	// ChecksumFailure is called for each response with a bad checksum
+	ChecksumFailure()
+}
+
+// WithMetrics reports the controller's activity to m
+func WithMetrics(m Metrics) Option {
+	return func(c *HanoverController) {
+		if m == nil {
+			m = nopMetrics{}
+		}
+		c.metrics = m
+	}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) FrameSent(string)         {}
+func (nopMetrics) FrameDropped(string)      {}
+func (nopMetrics) Write(int, time.Duration) {}
+func (nopMetrics) ReadTimeout()             {}
+func (nopMetrics) ChecksumFailure()         {}
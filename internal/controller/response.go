@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/harperreed/goflipdot/internal"
+	"github.com/harperreed/goflipdot/internal/packet"
+)
+
+const (
+	ackByte = 0x06
+	nakByte = 0x15
+)
+
+var (
+	ErrNoResponse        = errors.New("no response from sign")
+	ErrMalformedResponse = errors.New("malformed response from sign")
+	ErrRejected          = errors.New("sign rejected command")
+)
+
+// ResponseKind classifies a response from the signs
+type ResponseKind int
+
+const (
+	// ResponseNone means nothing was received before the response timeout
+	ResponseNone ResponseKind = iota
+	// ResponseAck is an ACK byte or a frame echoing the command sent
+	ResponseAck
+	// ResponseError is a NAK byte
+	ResponseError
+	// ResponseUnknown is a valid frame for another command, or data that
+	// could not be decoded
+	ResponseUnknown
+)
+
+func (k ResponseKind) String() string {
+	switch k {
+	case ResponseNone:
+		return "none"
+	case ResponseAck:
+		return "ack"
+	case ResponseError:
+		return "error"
+	case ResponseUnknown:
+		return "unknown"
+	}
+	return "invalid"
+}
+
+// Response is what the signs sent back after a command. Command, Address and
+// Payload are only set for framed responses.
+type Response struct {
+	Kind    ResponseKind
+	Command byte
+	Address int
+	Payload []byte
+	// Raw holds the bytes the response was decoded from
+	Raw []byte
+}
+
+// ResponsePolicy decides which responses make a command fail. Error
+// responses always fail with ErrRejected.
+type ResponsePolicy struct {
+	// RequireResponse fails commands that get no response with ErrNoResponse
+	RequireResponse bool
+	// RejectMalformed fails commands whose response cannot be decoded with
+	// ErrMalformedResponse
+	RejectMalformed bool
+}
+
+// WithResponsePolicy sets which responses make a command fail. By default
+// missing and malformed responses are returned without an error.
+func WithResponsePolicy(p ResponsePolicy) Option {
+	return func(c *HanoverController) {
+		c.responsePolicy = p
+	}
+}
+
+// parseResponse decodes the first response in data. complete is false if
+// more data is needed; err is set if the response is malformed.
+func parseResponse(data []byte, command byte) (resp Response, complete bool, err error) {
+	start := bytes.IndexAny(data, string([]byte{ackByte, nakByte, internal.StartByte}))
+	if start < 0 {
+		return Response{}, false, nil
+	}
+	switch data[start] {
+	case ackByte:
+		return Response{Kind: ResponseAck, Raw: data[start : start+1]}, true, nil
+	case nakByte:
+		return Response{Kind: ResponseError, Raw: data[start : start+1]}, true, nil
+	}
+
+	_, token, _ := packet.SplitFrames(data[start:], false)
+	if token == nil {
+		return Response{}, false, nil
+	}
+	resp = Response{Kind: ResponseUnknown, Raw: token}
+	f, err := packet.ParseFrame(token)
+	if err != nil {
+		return resp, true, fmt.Errorf("%w: %w", ErrMalformedResponse, err)
+	}
+	resp.Command, resp.Address, resp.Payload = f.Command, f.Address, f.Payload
+	if f.Command == command {
+		resp.Kind = ResponseAck
+	}
+	return resp, true, nil
+}
+
+// awaitResponse collects data from the reader until it holds a complete
+// response, the response timeout passes, ctx ends or the controller is
+// closed, and applies the response policy
+func (c *HanoverController) awaitResponse(ctx context.Context, command byte) (Response, error) {
+	timer := time.NewTimer(c.responseTimeout)
+	defer timer.Stop()
+
+	var data []byte
+	for {
+		select {
+		case chunk := <-c.responses:
+			data = append(data, chunk...)
+			resp, complete, err := parseResponse(data, command)
+			if !complete {
+				continue
+			}
+			if errors.Is(err, packet.ErrChecksum) {
+				c.metrics.ChecksumFailure()
+			}
+			c.logger.LogAttrs(ctx, slog.LevelDebug, "Received response",
+				slog.String("kind", resp.Kind.String()),
+				slog.Int("length", len(resp.Raw)),
+				slog.String("data", hex.EncodeToString(resp.Raw)),
+			)
+			return c.applyPolicy(resp, err)
+		case <-timer.C:
+			c.metrics.ReadTimeout()
+			if len(data) > 0 {
+				c.logger.LogAttrs(ctx, slog.LevelDebug, "Incomplete response",
+					slog.Duration("timeout", c.responseTimeout),
+					slog.Int("length", len(data)),
+					slog.String("data", hex.EncodeToString(data)),
+				)
+				resp := Response{Kind: ResponseUnknown, Raw: data}
+				return c.applyPolicy(resp, fmt.Errorf("%w: incomplete", ErrMalformedResponse))
+			}
+			c.logger.LogAttrs(ctx, slog.LevelDebug, "No response", slog.Duration("timeout", c.responseTimeout))
+			if c.responsePolicy.RequireResponse {
+				return Response{}, fmt.Errorf("%w after %v", ErrNoResponse, c.responseTimeout)
+			}
+			return Response{}, nil
+		case <-ctx.Done():
+			return Response{}, ctx.Err()
+		case <-c.closed:
+			return Response{}, ErrClosed
+		}
+	}
+}
+
+func (c *HanoverController) applyPolicy(resp Response, malformed error) (Response, error) {
+	switch {
+	case malformed != nil && c.responsePolicy.RejectMalformed:
+		return resp, malformed
+	case resp.Kind == ResponseError:
+		return resp, ErrRejected
+	}
+	return resp, nil
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+)
+
+// frame is an image packet along with the dots it was built from, before
+// orientation
+type frame struct {
+	data []byte
+	dots *bitmap.Bitmap
+}
+
+// frameScheduler paces the frames sent to one sign. A frame submitted before
+// the sign's next slot waits for it; if another frame arrives in the meantime
+// the waiting one is dropped, so the sign always shows the latest frame. It
+// also remembers the frame the sign is showing so identical frames can be
+// skipped.
+type frameScheduler struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+	pending  chan struct{}
+	dropped  uint64
+	shown    *frame
+	// onDrop is called, with mu held, for each dropped frame
+	onDrop func()
+	// notBefore, if set, gives a time before which no frame may be sent in
+	// addition to the scheduler's own interval
+	notBefore func() time.Time
+}
+
+func newFrameScheduler(maxFPS float64, onDrop func()) *frameScheduler {
+	s := &frameScheduler{onDrop: onDrop}
+	s.setMaxFPS(maxFPS)
+	return s
+}
+
+func (s *frameScheduler) setMaxFPS(maxFPS float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = 0
+	if maxFPS > 0 {
+		s.interval = time.Duration(float64(time.Second) / maxFPS)
+	}
+}
+
+// wait blocks until f may be sent. It returns false if f was superseded by a
+// newer frame while waiting, or, unless force is set, if the sign already
+// shows f. Once wait returns true f is taken to be shown; call failed if it
+// could not be sent.
+func (s *frameScheduler) wait(ctx context.Context, f *frame, force bool) (bool, error) {
+	s.mu.Lock()
+	if !force && s.shown != nil && bytes.Equal(s.shown.data, f.data) {
+		// The latest frame is already on the sign, so nothing pending is needed
+		if s.pending != nil {
+			close(s.pending)
+			s.pending = nil
+			s.drop()
+		}
+		s.mu.Unlock()
+		return false, nil
+	}
+	delay := s.delay()
+	if delay <= 0 && s.pending == nil {
+		s.last = time.Now()
+		s.shown = f
+		s.mu.Unlock()
+		return true, nil
+	}
+	if s.pending != nil {
+		close(s.pending)
+		s.drop()
+	}
+	superseded := make(chan struct{})
+	s.pending = superseded
+	s.mu.Unlock()
+
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-superseded:
+			timer.Stop()
+			return false, nil
+		case <-ctx.Done():
+			timer.Stop()
+			s.mu.Lock()
+			if s.pending == superseded {
+				s.pending = nil
+			}
+			s.mu.Unlock()
+			return false, ctx.Err()
+		}
+
+		s.mu.Lock()
+		if s.pending != superseded {
+			s.mu.Unlock()
+			return false, nil
+		}
+		// The slot may have moved while waiting, for example because a
+		// sign behind notBefore was drawn to directly
+		if delay = s.delay(); delay > 0 {
+			s.mu.Unlock()
+			continue
+		}
+		s.pending = nil
+		s.last = time.Now()
+		s.shown = f
+		s.mu.Unlock()
+		return true, nil
+	}
+}
+
+// delay returns how long until the next frame may be sent. Callers hold mu.
+func (s *frameScheduler) delay() time.Duration {
+	delay := time.Until(s.last.Add(s.interval))
+	if s.notBefore != nil {
+		delay = max(delay, time.Until(s.notBefore()))
+	}
+	return delay
+}
+
+// nextSlot returns the earliest time the next frame may be sent
+func (s *frameScheduler) nextSlot() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last.Add(s.interval)
+}
+
+// drop counts a pending frame that will never be sent. Callers hold mu.
+func (s *frameScheduler) drop() {
+	s.dropped++
+	if s.onDrop != nil {
+		s.onDrop()
+	}
+}
+
+// discard counts f as dropped when a frame for several signs that included
+// it is superseded before reaching wait's slot. Unless force is set, a frame
+// the sign already shows would have been skipped, so it is not counted.
+func (s *frameScheduler) discard(f *frame, force bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !force && s.shown != nil && bytes.Equal(s.shown.data, f.data) {
+		return
+	}
+	s.drop()
+}
+
+// show records that f is being sent to the sign outside of wait, which
+// takes up the sign's current slot. A frame waiting in wait is older than f,
+// so it is dropped. Unless force is set, show returns false without taking
+// the slot if the sign already shows f.
+func (s *frameScheduler) show(f *frame, force bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending != nil {
+		close(s.pending)
+		s.pending = nil
+		s.drop()
+	}
+	if !force && s.shown != nil && bytes.Equal(s.shown.data, f.data) {
+		return false
+	}
+	s.shown = f
+	s.last = time.Now()
+	return true
+}
+
+// failed records that f could not be sent, so what the sign shows is unknown
+func (s *frameScheduler) failed(f *frame) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shown == f {
+		s.shown = nil
+	}
+}
+
+// forget discards the shown frame, so the next frame is always sent
+func (s *frameScheduler) forget() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shown = nil
+}
+
+// shownDots returns the dots the sign is showing, or nil if unknown
+func (s *frameScheduler) shownDots() *bitmap.Bitmap {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shown == nil {
+		return nil
+	}
+	return s.shown.dots
+}
+
+func (s *frameScheduler) droppedFrames() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/harperreed/goflipdot/internal/packet"
+	"github.com/harperreed/goflipdot/internal/sign"
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+)
+
+var (
+	ErrDisplayAlreadyExists = errors.New("virtual display with this name already exists")
+	ErrDisplayNotFound      = errors.New("virtual display not found")
+	ErrInvalidTile          = errors.New("invalid tile")
+)
+
+// Tile places a sign on a virtual display. The sign covers the part of the
+// canvas starting at Offset and as large as the sign's image, so a sign
+// rotated with SetOrientation covers a rotated area.
+type Tile struct {
+	Sign   string
+	Offset image.Point
+}
+
+// VirtualDisplay draws one large canvas across several signs. Each frame is
+// split into one packet per sign and the packets are written to the bus
+// back-to-back, so the whole display updates together.
+type VirtualDisplay struct {
+	c         *HanoverController
+	width     int
+	height    int
+	tiles     []Tile
+	scheduler *frameScheduler
+}
+
+// AddVirtualDisplay registers a width x height display made of signs already
+// added to the controller. Every tile must lie within the canvas, and tiles
+// may neither share a sign nor overlap.
+func (c *HanoverController) AddVirtualDisplay(name string, width, height int, tiles []Tile) (*VirtualDisplay, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("%w: %dx%d", ErrInvalidImage, width, height)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.displays[name]; exists {
+		return nil, ErrDisplayAlreadyExists
+	}
+	if _, err := c.placeTiles(width, height, tiles); err != nil {
+		return nil, err
+	}
+	d := &VirtualDisplay{
+		c:      c,
+		width:  width,
+		height: height,
+		tiles:  append([]Tile(nil), tiles...),
+	}
+	// Drops are counted by the signs' schedulers, see DrawContext
+	d.scheduler = newFrameScheduler(c.maxFPS, nil)
+	d.scheduler.notBefore = d.nextSlot
+	c.displays[name] = d
+	return d, nil
+}
+
+// VirtualDisplay returns the named virtual display
+func (c *HanoverController) VirtualDisplay(name string) (*VirtualDisplay, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	d, ok := c.displays[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDisplayNotFound, name)
+	}
+	return d, nil
+}
+
+// placement is a tile's sign, the name it was added under and the area of
+// the canvas it covers
+type placement struct {
+	name string
+	sign *sign.HanoverSign
+	area image.Rectangle
+}
+
+// placeTiles finds the area of a width x height canvas each tile covers. A
+// sign's area follows its current orientation, so tiles are checked again
+// whenever they are drawn. The caller must hold c.mu.
+func (c *HanoverController) placeTiles(width, height int, tiles []Tile) ([]placement, error) {
+	canvas := image.Rect(0, 0, width, height)
+	placed := make([]placement, 0, len(tiles))
+	for _, tile := range tiles {
+		name, s, err := c.lookupSign(tile.Sign)
+		if err != nil {
+			return nil, err
+		}
+		w, h := s.ImageSize()
+		area := image.Rect(0, 0, w, h).Add(tile.Offset)
+		if !area.In(canvas) {
+			return nil, fmt.Errorf("%w: sign %s at %v does not fit a %dx%d display", ErrInvalidTile, tile.Sign, area, width, height)
+		}
+		for _, p := range placed {
+			if p.sign == s {
+				return nil, fmt.Errorf("%w: sign %s is placed twice", ErrInvalidTile, tile.Sign)
+			}
+			if p.area.Overlaps(area) {
+				return nil, fmt.Errorf("%w: sign %s at %v overlaps another tile at %v", ErrInvalidTile, tile.Sign, area, p.area)
+			}
+		}
+		placed = append(placed, placement{name: name, sign: s, area: area})
+	}
+	return placed, nil
+}
+
+// nextSlot returns the time by which every sign of the display may be sent
+// another frame, so the display runs no faster than its slowest sign
+func (d *VirtualDisplay) nextSlot() time.Time {
+	d.c.mu.RLock()
+	defer d.c.mu.RUnlock()
+	var next time.Time
+	for _, tile := range d.tiles {
+		s, err := d.c.getSign(tile.Sign)
+		if err != nil {
+			continue
+		}
+		if slot := d.c.schedulers[s].nextSlot(); slot.After(next) {
+			next = slot
+		}
+	}
+	return next
+}
+
+// CreateImage creates a blank image the size of the display
+func (d *VirtualDisplay) CreateImage() *image.Gray {
+	return image.NewGray(image.Rect(0, 0, d.width, d.height))
+}
+
+// SetMaxFPS limits how many frames per second are drawn to the display. Zero
+// removes this limit, but the limits of the display's signs still apply.
+func (d *VirtualDisplay) SetMaxFPS(fps float64) error {
+	if fps < 0 {
+		return fmt.Errorf("invalid frame rate %v", fps)
+	}
+	d.scheduler.setMaxFPS(fps)
+	return nil
+}
+
+// Draw sends img across the display's signs
+func (d *VirtualDisplay) Draw(img *image.Gray, opts ...DrawOption) error {
+	return d.DrawContext(context.Background(), img, opts...)
+}
+
+// DrawContext sends img across the display's signs, giving up when ctx ends.
+// Signs whose part of the image is unchanged are skipped unless Force is
+// given. Frames are paced by the display's own limit and by the limit of
+// every sign on it, and are coalesced as for DrawImageContext.
+func (d *VirtualDisplay) DrawContext(ctx context.Context, img *image.Gray, opts ...DrawOption) error {
+	var cfg drawConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	select {
+	case <-d.c.closed:
+		return ErrClosed
+	default:
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() != d.width || bounds.Dy() != d.height {
+		return fmt.Errorf("%w: image is %dx%d, display is %dx%d", ErrInvalidImage, bounds.Dx(), bounds.Dy(), d.width, d.height)
+	}
+
+	// Split the frame before waiting so every part comes from the same image
+	type part struct {
+		sign      string
+		scheduler *frameScheduler
+		frame     *frame
+	}
+	d.c.mu.RLock()
+	placed, err := d.c.placeTiles(d.width, d.height, d.tiles)
+	if err != nil {
+		d.c.mu.RUnlock()
+		return err
+	}
+	signs := make([]sign.HanoverSign, len(placed))
+	schedulers := make([]*frameScheduler, len(placed))
+	for i, p := range placed {
+		signs[i], schedulers[i] = *p.sign, d.c.schedulers[p.sign]
+	}
+	d.c.mu.RUnlock()
+
+	parts := make([]part, 0, len(d.tiles))
+	var all []byte
+	for i, tile := range d.tiles {
+		sign, scheduler := signs[i], schedulers[i]
+		sub := img.SubImage(placed[i].area.Add(bounds.Min)).(*image.Gray)
+		if err := sign.ValidateImage(sub); err != nil {
+			return fmt.Errorf("sign %s: %w", tile.Sign, err)
+		}
+		f, err := encodeFrame(packet.ImagePacket{
+			Address: sign.Address,
+			Image:   sign.OrientImage(sub),
+		}, bitmap.FromImage(sub))
+		if err != nil {
+			return err
+		}
+		parts = append(parts, part{placed[i].name, scheduler, f})
+		all = append(all, f.data...)
+	}
+
+	// Pace whole frames; unchanged signs are filtered out below
+	if send, err := d.scheduler.wait(ctx, &frame{data: all}, true); !send {
+		if err == nil {
+			// Superseded by a newer display frame, which drops the part for
+			// each sign it would have changed
+			for _, p := range parts {
+				p.scheduler.discard(p.frame, cfg.force)
+			}
+		}
+		return err
+	}
+	var batch []byte
+	var sent []part
+	for _, p := range parts {
+		if !p.scheduler.show(p.frame, cfg.force) {
+			continue
+		}
+		batch = append(batch, p.frame.data...)
+		sent = append(sent, p)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	if _, err := d.c.send(ctx, batch, false); err != nil {
+		for _, p := range sent {
+			p.scheduler.failed(p.frame)
+		}
+		return err
+	}
+	for _, p := range sent {
+		d.c.metrics.FrameSent(p.sign)
+	}
+	return nil
+}
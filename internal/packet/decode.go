@@ -0,0 +1,183 @@
+package packet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/harperreed/goflipdot/internal"
+)
+
+// maxFrameLength bounds how much data is buffered while looking for an end byte
+const maxFrameLength = 4096
+
+var (
+	ErrChecksum        = errors.New("checksum mismatch")
+	ErrMalformedPacket = errors.New("malformed packet")
+	ErrUnknownCommand  = errors.New("unknown command")
+)
+
+// Frame is a raw frame whose checksum has been verified
+type Frame struct {
+	Command byte
+	Address int
+	// Payload is the ASCII hex text between the address and the end byte
+	Payload []byte
+}
+
+// ParseFrame splits a complete frame, from start byte through checksum, into
+// its fields and verifies the checksum
+func ParseFrame(b []byte) (Frame, error) {
+	if len(b) < 6 || b[0] != internal.StartByte || b[len(b)-3] != internal.EndByte {
+		return Frame{}, fmt.Errorf("%w: bad framing", ErrMalformedPacket)
+	}
+	var checksum [1]byte
+	if _, err := hex.Decode(checksum[:], b[len(b)-2:]); err != nil {
+		return Frame{}, fmt.Errorf("%w: bad checksum digits", ErrMalformedPacket)
+	}
+	body := b[:len(b)-2]
+	if want := internal.Checksum(body); checksum[0] != want {
+		return Frame{}, fmt.Errorf("%w: got %02X, want %02X", ErrChecksum, checksum[0], want)
+	}
+	address, ok := hexDigit(b[2])
+	if !ok {
+		return Frame{}, fmt.Errorf("%w: bad address %q", ErrMalformedPacket, b[2])
+	}
+	return Frame{
+		Command: b[1],
+		Address: address,
+		Payload: b[3 : len(b)-3],
+	}, nil
+}
+
+// Packet converts the frame into a typed Packet. Images are reconstructed
+// with height rows and as many columns as the payload carries.
+func (f Frame) Packet(height int) (Packet, error) {
+	switch f.Command {
+	case internal.CommandStartTest:
+		return TestSignsStartPacket{}, nil
+	case internal.CommandStopTest:
+		return TestSignsStopPacket{}, nil
+	case internal.CommandWriteImage:
+		img, err := decodeImage(f.Payload, height)
+		if err != nil {
+			return nil, err
+		}
+		return ImagePacket{Address: f.Address, Image: img}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCommand, f.Command)
+	}
+}
+
+// Decode parses a complete frame into a typed Packet, reconstructing images
+// with height rows
+func Decode(b []byte, height int) (Packet, error) {
+	f, err := ParseFrame(b)
+	if err != nil {
+		return nil, err
+	}
+	return f.Packet(height)
+}
+
+// SplitFrames is a bufio.SplitFunc that yields complete frames, from start
+// byte through checksum, discarding any noise between them
+func SplitFrames(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := bytes.IndexByte(data, internal.StartByte)
+	if start < 0 {
+		return len(data), nil, nil
+	}
+	end := bytes.IndexByte(data[start:], internal.EndByte)
+	if end < 0 {
+		if len(data)-start > maxFrameLength {
+			// No end byte in sight, so this start byte was noise
+			return start + 1, nil, nil
+		}
+		if atEOF {
+			return len(data), nil, nil
+		}
+		return start, nil, nil
+	}
+	frameEnd := start + end + 3
+	if len(data) < frameEnd {
+		if atEOF {
+			return len(data), nil, nil
+		}
+		return start, nil, nil
+	}
+	return frameEnd, data[start:frameEnd], nil
+}
+
+// Scanner reads packets from a byte stream
+type Scanner struct {
+	s      *bufio.Scanner
+	height int
+}
+
+// NewScanner creates a Scanner reading from r that reconstructs images with
+// height rows
+func NewScanner(r io.Reader, height int) *Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 512), maxFrameLength*2)
+	s.Split(SplitFrames)
+	return &Scanner{s: s, height: height}
+}
+
+// Next returns the next packet in the stream. Errors wrapping ErrChecksum,
+// ErrMalformedPacket or ErrUnknownCommand only affect the current frame and
+// scanning may continue. At the end of the stream Next returns io.EOF.
+func (s *Scanner) Next() (Packet, error) {
+	if !s.s.Scan() {
+		if err := s.s.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return Decode(s.s.Bytes(), s.height)
+}
+
+// decodeImage reverses imageToBytes on an ASCII hex image payload
+func decodeImage(payload []byte, height int) (*image.Gray, error) {
+	if height <= 0 {
+		return nil, fmt.Errorf("%w: height must be positive", ErrInvalidImage)
+	}
+	if len(payload) < 2 || len(payload)%2 != 0 {
+		return nil, fmt.Errorf("%w: bad image payload length %d", ErrMalformedPacket, len(payload))
+	}
+	var resolution [1]byte
+	if _, err := hex.Decode(resolution[:], payload[:2]); err != nil {
+		return nil, fmt.Errorf("%w: bad resolution digits", ErrMalformedPacket)
+	}
+	data := make([]byte, len(payload)/2-1)
+	if _, err := hex.Decode(data, payload[2:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPacket, err)
+	}
+	if int(resolution[0]) != len(data) {
+		return nil, fmt.Errorf("%w: resolution field says %d bytes, got %d", ErrMalformedPacket, resolution[0], len(data))
+	}
+	bytesPerColumn := (height + 7) / 8
+	if len(data) == 0 || len(data)%bytesPerColumn != 0 {
+		return nil, fmt.Errorf("%w: %d data bytes for %d rows", ErrMalformedPacket, len(data), height)
+	}
+
+	width := len(data) / bytesPerColumn
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if data[x*bytesPerColumn+y/8]&(1<<uint(y%8)) != 0 {
+				img.SetGray(x, height-1-y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return img, nil
+}
+
+func hexDigit(b byte) (int, bool) {
+	i := strings.IndexByte(hexDigits, b)
+	return i, i >= 0
+}
@@ -1,21 +1,31 @@
 package packet
 
 import (
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"image"
+
+	"github.com/harperreed/goflipdot/internal"
 )
 
 const (
-	startByte byte = 0x02
-	endByte   byte = 0x03
+	// MaxAddress is the highest address that fits in the single hex digit
+	// address field
+	MaxAddress = 0xF
+
+	// MaxDataLength is the largest image, in bytes, that fits in the single
+	// byte resolution field
+	MaxDataLength = 0xFF
 )
 
 var (
-	ErrInvalidImage = errors.New("invalid image")
+	ErrInvalidImage   = errors.New("invalid image")
+	ErrInvalidAddress = errors.New("invalid sign address")
+	ErrImageTooLarge  = errors.New("image too large for one packet")
 )
 
+const hexDigits = "0123456789ABCDEF"
+
 // Packet represents a data packet for Hanover signs
 type Packet interface {
 	GetBytes() ([]byte, error)
@@ -25,81 +35,97 @@ type Packet interface {
 type TestSignsStartPacket struct{}
 
 func (p TestSignsStartPacket) GetBytes() ([]byte, error) {
-	return []byte{startByte, '3', '0', endByte, '9', 'A'}, nil
+	return internal.FormatPacket(internal.CommandStartTest, '0', nil), nil
 }
 
 // TestSignsStopPacket is a command for all signs to stop test mode sequence
 type TestSignsStopPacket struct{}
 
 func (p TestSignsStopPacket) GetBytes() ([]byte, error) {
-	return []byte{startByte, 'C', '0', endByte, '8', 'A'}, nil
+	return internal.FormatPacket(internal.CommandStopTest, '0', nil), nil
 }
 
-// ImagePacket encodes an image to display
+// Dots is a 1-bit image with its origin at (0, 0), such as a *bitmap.Bitmap
+type Dots interface {
+	Bounds() image.Rectangle
+	Get(x, y int) bool
+}
+
+// ImagePacket encodes an image to display. If Dots is set it is encoded
+// instead of Image.
 type ImagePacket struct {
 	Address int
 	Image   *image.Gray
+	Dots    Dots
 }
 
 func (p ImagePacket) GetBytes() ([]byte, error) {
-    if p.Image == nil {
-        return nil, ErrInvalidImage
-    }
-
-    bounds := p.Image.Bounds()
-    width, height := bounds.Dx(), bounds.Dy()
-    resolution := (width * height) / 8
-    resolutionStr := fmt.Sprintf("%02X", resolution)
-
-    imageBytes, err := imageToBytes(p.Image)
-    if err != nil {
-        return nil, fmt.Errorf("failed to convert image to bytes: %w", err)
-    }
-
-    packet := make([]byte, 0, 5+len(imageBytes)*2+3)
-    packet = append(packet, startByte)
-    packet = append(packet, '1')
-    packet = append(packet, byte(p.Address+'0'))
-    packet = append(packet, []byte(resolutionStr)...)
-
-    encodedImageBytes := make([]byte, len(imageBytes)*2)
-    hex.Encode(encodedImageBytes, imageBytes)
-    packet = append(packet, encodedImageBytes...)
-
-    packet = append(packet, endByte)
-
-    checksum := calculateChecksum(packet)
-    checksumStr := fmt.Sprintf("%02X", checksum)
-    packet = append(packet, []byte(checksumStr)...)
+	var bounds image.Rectangle
+	var lit func(x, y int) bool
+	switch {
+	case p.Dots != nil:
+		bounds, lit = p.Dots.Bounds(), p.Dots.Get
+	case p.Image != nil:
+		bounds = p.Image.Bounds()
+		lit = func(x, y int) bool {
+			return p.Image.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y >= internal.Threshold
+		}
+	default:
+		return nil, ErrInvalidImage
+	}
+	address, err := EncodeAddress(p.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	dataLength, err := DataLength(bounds.Dx(), bounds.Dy())
+	if err != nil {
+		return nil, err
+	}
+	imageBytes := columnBytes(bounds.Dx(), bounds.Dy(), lit)
+
+	payload := internal.ToAsciiHex([]byte{byte(dataLength)})
+	payload = append(payload, internal.ToAsciiHex(imageBytes)...)
+	return internal.FormatPacket(internal.CommandWriteImage, address, payload), nil
+}
 
-    return packet, nil
+// DataLength returns the number of image bytes sent for a width x height
+// image. Each column is padded to a whole number of bytes, and the total must
+// fit in the resolution field.
+func DataLength(width, height int) (int, error) {
+	if width <= 0 || height <= 0 {
+		return 0, fmt.Errorf("%w: %dx%d", ErrInvalidImage, width, height)
+	}
+	length := width * ((height + 7) / 8)
+	if length > MaxDataLength {
+		return 0, fmt.Errorf("%w: %dx%d needs %d bytes, limit is %d", ErrImageTooLarge, width, height, length, MaxDataLength)
+	}
+	return length, nil
 }
 
-func calculateChecksum(data []byte) byte {
-    var sum int
-    for _, b := range data {
-        sum += int(b)
-    }
-    sum -= int(startByte)
-    sum = sum & 0xFF
-    return byte((sum ^ 0xFF) + 1)
+// EncodeAddress returns the ASCII hex digit used for address on the wire
+func EncodeAddress(address int) (byte, error) {
+	if address < 0 || address > MaxAddress {
+		return 0, fmt.Errorf("%w: %d is outside 0-%d", ErrInvalidAddress, address, MaxAddress)
+	}
+	return hexDigits[address], nil
 }
 
-func imageToBytes(img *image.Gray) ([]byte, error) {
-    bounds := img.Bounds()
-    width, height := bounds.Dx(), bounds.Dy()
-    bytesPerColumn := (height + 7) / 8
-    result := make([]byte, width*bytesPerColumn)
-
-    for x := 0; x < width; x++ {
-        for y := 0; y < height; y++ {
-            if img.GrayAt(x, height-1-y).Y > 127 { // Flip vertically
-                byteIndex := x*bytesPerColumn + (y / 8)
-                bitIndex := uint(y % 8)
-                result[byteIndex] |= 1 << bitIndex
-            }
-        }
-    }
-
-    return result, nil
+// columnBytes packs a width x height image column by column, bottom row
+// first, as the signs expect. lit reports whether the dot at (x, y) is on.
+func columnBytes(width, height int, lit func(x, y int) bool) []byte {
+	bytesPerColumn := (height + 7) / 8
+	result := make([]byte, width*bytesPerColumn)
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if lit(x, height-1-y) { // Flip vertically
+				byteIndex := x*bytesPerColumn + (y / 8)
+				bitIndex := uint(y % 8)
+				result[byteIndex] |= 1 << bitIndex
+			}
+		}
+	}
+
+	return result
 }
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// BaudRate is the line speed used by Hanover signs
+const BaudRate = 4800
+
+const (
+	StartByte byte = 0x02
+	EndByte   byte = 0x03
+)
+
+// Threshold is the gray level at which a pixel lights a dot
+const Threshold = 128
+
+// Command codes sent in the first byte after StartByte
+const (
+	CommandWriteImage byte = '1'
+	CommandStartTest  byte = '3'
+	CommandStopTest   byte = 'C'
+)
+
+// CommandCodes maps command names to their codes
+var CommandCodes = map[string]byte{
+	"write_image":      CommandWriteImage,
+	"start_test_signs": CommandStartTest,
+	"stop_test_signs":  CommandStopTest,
+}
+
+// FormatPacket frames a command for the bus: start byte, command code,
+// address digit, payload, end byte and a two digit checksum. The payload must
+// already be ASCII hex.
+func FormatPacket(command, address byte, payload []byte) []byte {
+	packet := make([]byte, 0, 3+len(payload)+3)
+	packet = append(packet, StartByte, command, address)
+	packet = append(packet, payload...)
+	packet = append(packet, EndByte)
+	return append(packet, ToAsciiHex([]byte{Checksum(packet)})...)
+}
+
+// ToAsciiHex encodes data as upper case ASCII hex, two characters per byte
+func ToAsciiHex(data []byte) []byte {
+	return []byte(strings.ToUpper(hex.EncodeToString(data)))
+}
+
+// Checksum returns the checksum byte for a frame running from the start byte
+// through the end byte
+func Checksum(data []byte) byte {
+	var sum int
+	for _, b := range data {
+		sum += int(b)
+	}
+	sum -= int(StartByte)
+	sum = sum & 0xFF
+	return byte((sum ^ 0xFF) + 1)
+}
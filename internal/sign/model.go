@@ -0,0 +1,117 @@
+package sign
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/harperreed/goflipdot/internal/packet"
+)
+
+// DefaultRefreshRate is the maximum frames per second for models registered
+// without a RefreshRate. Hanover does not publish rates per model, so every
+// built-in model uses it; it is the 2 Hz the flip mechanism is known to keep
+// up with.
+const DefaultRefreshRate = 2
+
+var (
+	ErrUnknownModel = errors.New("unknown sign model")
+)
+
+// Model describes a Hanover panel. Names follow the series, a dash and the
+// columns x rows, as in "7000-28x19".
+type Model struct {
+	Name    string
+	Columns int
+	Rows    int
+	// RefreshRate is the recommended maximum frames per second. Zero means
+	// DefaultRefreshRate.
+	RefreshRate float64
+}
+
+// ColumnBytes returns how many bytes each column takes on the wire. Rows are
+// padded to a whole byte.
+func (m Model) ColumnBytes() int {
+	return (m.Rows + 7) / 8
+}
+
+// Validate checks that images for the model fit in an image packet
+func (m Model) Validate() error {
+	if m.Name == "" {
+		return errors.New("model name must not be empty")
+	}
+	if m.RefreshRate < 0 {
+		return fmt.Errorf("model %s: invalid refresh rate %v", m.Name, m.RefreshRate)
+	}
+	if _, err := packet.DataLength(m.Columns, m.Rows); err != nil {
+		return fmt.Errorf("model %s: %w", m.Name, err)
+	}
+	return nil
+}
+
+var (
+	modelsMu sync.RWMutex
+	models   = map[string]Model{}
+)
+
+func init() {
+	for _, m := range []Model{
+		{Name: "7000-28x16", Columns: 28, Rows: 16},
+		{Name: "7000-28x19", Columns: 28, Rows: 19},
+		{Name: "7000-40x19", Columns: 40, Rows: 19},
+		{Name: "7000-56x16", Columns: 56, Rows: 16},
+		{Name: "7000-56x19", Columns: 56, Rows: 19},
+		{Name: "7000-84x7", Columns: 84, Rows: 7},
+		{Name: "7000-84x16", Columns: 84, Rows: 16},
+		{Name: "7000-86x7", Columns: 86, Rows: 7},
+		{Name: "7000-96x8", Columns: 96, Rows: 8},
+		{Name: "7000-96x16", Columns: 96, Rows: 16},
+		{Name: "7000-112x16", Columns: 112, Rows: 16},
+		{Name: "7000-126x16", Columns: 126, Rows: 16},
+	} {
+		if err := RegisterModel(m); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterModel adds or replaces a model in the catalog. A model without a
+// RefreshRate gets DefaultRefreshRate.
+func RegisterModel(m Model) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	if m.RefreshRate == 0 {
+		m.RefreshRate = DefaultRefreshRate
+	}
+	modelsMu.Lock()
+	defer modelsMu.Unlock()
+	models[m.Name] = m
+	return nil
+}
+
+// LookupModel returns the named model from the catalog
+func LookupModel(name string) (Model, error) {
+	modelsMu.RLock()
+	defer modelsMu.RUnlock()
+	m, ok := models[name]
+	if !ok {
+		return Model{}, fmt.Errorf("%w: %s", ErrUnknownModel, name)
+	}
+	return m, nil
+}
+
+// Models returns the catalog sorted by name
+func Models() []Model {
+	modelsMu.RLock()
+	defer modelsMu.RUnlock()
+	list := make([]Model, 0, len(models))
+	for _, m := range models {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
@@ -2,40 +2,199 @@ package sign
 
 import (
 	"errors"
+	"fmt"
 	"image"
+
+	"github.com/harperreed/goflipdot/internal/packet"
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+)
+
+var (
+	ErrInvalidOrientation = errors.New("invalid orientation")
+)
+
+// Rotation is a clockwise rotation in degrees applied when sending images
+type Rotation int
+
+const (
+	Rotate0   Rotation = 0
+	Rotate90  Rotation = 90
+	Rotate180 Rotation = 180
+	Rotate270 Rotation = 270
 )
 
+// Orientation describes how a panel is mounted relative to the images drawn
+// for it. Mirroring is applied first, then rotation.
+type Orientation struct {
+	Rotation         Rotation
+	MirrorHorizontal bool
+	MirrorVertical   bool
+}
+
+// Validate checks that the rotation is a multiple of 90 degrees
+func (o Orientation) Validate() error {
+	switch o.Rotation {
+	case Rotate0, Rotate90, Rotate180, Rotate270:
+		return nil
+	}
+	return fmt.Errorf("%w: rotation %d", ErrInvalidOrientation, o.Rotation)
+}
+
+// HanoverSign describes a single sign on the bus. Width and Height are the
+// physical dimensions of the panel.
 type HanoverSign struct {
-	Address int
-	Width   int
-	Height  int
+	Address     int
+	Width       int
+	Height      int
+	Flip        bool
+	Orientation Orientation
 }
 
-func NewHanoverSign(address, width, height int) (*HanoverSign, error) {
+// NewHanoverSign creates a sign, rejecting addresses and sizes the protocol
+// cannot encode
+func NewHanoverSign(address, width, height int, flip bool) (*HanoverSign, error) {
 	if width <= 0 || height <= 0 {
 		return nil, errors.New("width and height must be positive")
 	}
-	if address < 0 {
-		return nil, errors.New("address must be non-negative")
+	if _, err := packet.EncodeAddress(address); err != nil {
+		return nil, err
+	}
+	if _, err := packet.DataLength(width, height); err != nil {
+		return nil, err
 	}
 	return &HanoverSign{
 		Address: address,
 		Width:   width,
 		Height:  height,
+		Flip:    flip,
 	}, nil
 }
 
+// ImageSize returns the dimensions of images drawn for the sign, which are
+// swapped relative to the panel when it is rotated by 90 or 270 degrees
+func (s *HanoverSign) ImageSize() (width, height int) {
+	if s.Orientation.Rotation == Rotate90 || s.Orientation.Rotation == Rotate270 {
+		return s.Height, s.Width
+	}
+	return s.Width, s.Height
+}
+
 func (s *HanoverSign) CreateImage() *image.Gray {
-	return image.NewGray(image.Rect(0, 0, s.Width, s.Height))
+	width, height := s.ImageSize()
+	return image.NewGray(image.Rect(0, 0, width, height))
 }
 
 func (s *HanoverSign) ValidateImage(img *image.Gray) error {
 	if img == nil {
 		return errors.New("image cannot be nil")
 	}
-	bounds := img.Bounds()
-	if bounds.Dx() != s.Width || bounds.Dy() != s.Height {
+	return s.validateSize(img.Bounds())
+}
+
+// ValidateBitmap checks that b is the size of images drawn for the sign
+func (s *HanoverSign) ValidateBitmap(b *bitmap.Bitmap) error {
+	if b == nil {
+		return errors.New("bitmap cannot be nil")
+	}
+	return s.validateSize(b.Bounds())
+}
+
+func (s *HanoverSign) validateSize(bounds image.Rectangle) error {
+	if err := s.Orientation.Validate(); err != nil {
+		return err
+	}
+	width, height := s.ImageSize()
+	if bounds.Dx() != width || bounds.Dy() != height {
 		return errors.New("image dimensions do not match sign dimensions")
 	}
 	return nil
 }
+
+// FlipImage rotates img by 180 degrees if the sign is mounted upside down
+func (s *HanoverSign) FlipImage(img *image.Gray) *image.Gray {
+	if !s.Flip {
+		return img
+	}
+	return transform(img, Orientation{Rotation: Rotate180})
+}
+
+// OrientImage converts an image drawn for the sign into the physical layout
+// of the panel by applying Orientation and then Flip
+func (s *HanoverSign) OrientImage(img *image.Gray) *image.Gray {
+	o := s.mounting()
+	if o == (Orientation{}) {
+		return img
+	}
+	return transform(img, o)
+}
+
+// OrientBitmap converts a bitmap drawn for the sign into the physical layout
+// of the panel, like OrientImage
+func (s *HanoverSign) OrientBitmap(b *bitmap.Bitmap) *bitmap.Bitmap {
+	o := s.mounting()
+	if o == (Orientation{}) {
+		return b
+	}
+	out := bitmap.New(o.size(b.Width, b.Height))
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			if b.Get(x, y) {
+				dx, dy := o.place(x, y, b.Width, b.Height)
+				out.SetBit(dx, dy, true)
+			}
+		}
+	}
+	return out
+}
+
+// mounting returns Orientation combined with Flip
+func (s *HanoverSign) mounting() Orientation {
+	o := s.Orientation
+	if s.Flip {
+		o.Rotation = (o.Rotation + Rotate180) % 360
+	}
+	return o
+}
+
+// size returns the size of a width x height image after o is applied
+func (o Orientation) size(width, height int) (int, int) {
+	if o.Rotation == Rotate90 || o.Rotation == Rotate270 {
+		return height, width
+	}
+	return width, height
+}
+
+// place returns where the dot at (x, y) of a width x height image ends up
+// once o is applied
+func (o Orientation) place(x, y, width, height int) (int, int) {
+	if o.MirrorHorizontal {
+		x = width - 1 - x
+	}
+	if o.MirrorVertical {
+		y = height - 1 - y
+	}
+	switch o.Rotation {
+	case Rotate90:
+		return height - 1 - y, x
+	case Rotate180:
+		return width - 1 - x, height - 1 - y
+	case Rotate270:
+		return y, width - 1 - x
+	}
+	return x, y
+}
+
+func transform(img *image.Gray, o Orientation) *image.Gray {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	outWidth, outHeight := o.size(width, height)
+	out := image.NewGray(image.Rect(0, 0, outWidth, outHeight))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dx, dy := o.place(x, y, width, height)
+			out.SetGray(dx, dy, img.GrayAt(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/harperreed/goflipdot/internal"
+	"github.com/tarm/serial"
+)
+
+var (
+	ErrNilTransport = errors.New("transport cannot be nil")
+)
+
+// Transport is a byte stream connected to a bus of Hanover signs
+type Transport interface {
+	io.ReadWriteCloser
+}
+
+// OpenSerial opens a serial port configured for Hanover signs
+func OpenSerial(name string) (Transport, error) {
+	c := &serial.Config{Name: name, Baud: internal.BaudRate}
+	port, err := serial.OpenPort(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open serial port: %w", err)
+	}
+	return port, nil
+}
+
+// New wraps rw as a Transport. If rw implements io.Closer, closing the
+// Transport closes rw; otherwise Close is a no-op.
+func New(rw io.ReadWriter) (Transport, error) {
+	if rw == nil {
+		return nil, ErrNilTransport
+	}
+	if t, ok := rw.(Transport); ok {
+		return t, nil
+	}
+	return nopCloser{rw}, nil
+}
+
+type nopCloser struct {
+	io.ReadWriter
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
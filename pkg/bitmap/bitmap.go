@@ -0,0 +1,219 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"math/bits"
+
+	"github.com/harperreed/goflipdot/internal"
+)
+
+// Threshold is the gray level at which a color counts as a lit dot. It
+// matches the threshold used when images are sent to a sign.
+const Threshold = internal.Threshold
+
+var (
+	// Off and On are the colors Bitmap.At returns
+	Off = color.Gray{Y: 0}
+	On  = color.Gray{Y: 255}
+
+	// Model converts any color to Off or On
+	Model = color.ModelFunc(func(c color.Color) color.Color {
+		if IsOn(c) {
+			return On
+		}
+		return Off
+	})
+)
+
+// IsOn reports whether c lights a dot
+func IsOn(c color.Color) bool {
+	return color.GrayModel.Convert(c).(color.Gray).Y >= Threshold
+}
+
+// Bitmap is a packed 1-bit image with its origin at (0, 0). Each row is
+// Stride bytes, most significant bit first; bits past Width are always zero.
+type Bitmap struct {
+	Width  int
+	Height int
+	Stride int
+	Pix    []byte
+}
+
+// New creates a blank width x height bitmap
+func New(width, height int) *Bitmap {
+	if width < 0 || height < 0 {
+		width, height = 0, 0
+	}
+	stride := (width + 7) / 8
+	return &Bitmap{
+		Width:  width,
+		Height: height,
+		Stride: stride,
+		Pix:    make([]byte, stride*height),
+	}
+}
+
+// FromImage converts img to a bitmap the size of its bounds
+func FromImage(img image.Image) *Bitmap {
+	bounds := img.Bounds()
+	b := New(bounds.Dx(), bounds.Dy())
+	if gray, ok := img.(*image.Gray); ok {
+		for y := 0; y < b.Height; y++ {
+			row := gray.Pix[gray.PixOffset(bounds.Min.X, bounds.Min.Y+y):]
+			for x := 0; x < b.Width; x++ {
+				if row[x] >= Threshold {
+					b.Pix[y*b.Stride+x/8] |= 0x80 >> uint(x%8)
+				}
+			}
+		}
+		return b
+	}
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			b.SetBit(x, y, IsOn(img.At(bounds.Min.X+x, bounds.Min.Y+y)))
+		}
+	}
+	return b
+}
+
+// ColorModel returns Model
+func (b *Bitmap) ColorModel() color.Model {
+	return Model
+}
+
+// Bounds returns the bitmap's rectangle
+func (b *Bitmap) Bounds() image.Rectangle {
+	return image.Rect(0, 0, b.Width, b.Height)
+}
+
+// At returns On or Off
+func (b *Bitmap) At(x, y int) color.Color {
+	if b.Get(x, y) {
+		return On
+	}
+	return Off
+}
+
+// Set lights the dot at (x, y) if c is bright enough, and clears it otherwise
+func (b *Bitmap) Set(x, y int, c color.Color) {
+	b.SetBit(x, y, IsOn(c))
+}
+
+// Get reports whether the dot at (x, y) is lit. Dots outside the bitmap are
+// never lit.
+func (b *Bitmap) Get(x, y int) bool {
+	if x < 0 || y < 0 || x >= b.Width || y >= b.Height {
+		return false
+	}
+	return b.Pix[y*b.Stride+x/8]&(0x80>>uint(x%8)) != 0
+}
+
+// SetBit lights or clears the dot at (x, y)
+func (b *Bitmap) SetBit(x, y int, on bool) {
+	if x < 0 || y < 0 || x >= b.Width || y >= b.Height {
+		return
+	}
+	i, mask := y*b.Stride+x/8, byte(0x80>>uint(x%8))
+	if on {
+		b.Pix[i] |= mask
+	} else {
+		b.Pix[i] &^= mask
+	}
+}
+
+// Fill lights or clears every dot
+func (b *Bitmap) Fill(on bool) {
+	v := byte(0)
+	if on {
+		v = 0xFF
+	}
+	for i := range b.Pix {
+		b.Pix[i] = v
+	}
+	b.clearPadding()
+}
+
+// Invert flips every dot
+func (b *Bitmap) Invert() {
+	for i := range b.Pix {
+		b.Pix[i] = ^b.Pix[i]
+	}
+	b.clearPadding()
+}
+
+// Blit copies the sr part of src to b with its top-left corner at dp. Dots
+// outside either bitmap are skipped.
+func (b *Bitmap) Blit(src *Bitmap, sr image.Rectangle, dp image.Point) {
+	sr = sr.Intersect(src.Bounds())
+	dr := sr.Sub(sr.Min).Add(dp).Intersect(b.Bounds())
+	sp := sr.Min.Add(dr.Min.Sub(dp))
+	if dr.Empty() {
+		return
+	}
+	// Copying from a bitmap onto itself must not read dots already written
+	if src == b {
+		src = src.Clone()
+	}
+	for y := 0; y < dr.Dy(); y++ {
+		for x := 0; x < dr.Dx(); x++ {
+			b.SetBit(dr.Min.X+x, dr.Min.Y+y, src.Get(sp.X+x, sp.Y+y))
+		}
+	}
+}
+
+// Count returns the number of lit dots
+func (b *Bitmap) Count() int {
+	n := 0
+	for _, v := range b.Pix {
+		n += bits.OnesCount8(v)
+	}
+	return n
+}
+
+// Equal reports whether two bitmaps are the same size with the same dots lit
+func (b *Bitmap) Equal(other *Bitmap) bool {
+	if b.Width != other.Width || b.Height != other.Height {
+		return false
+	}
+	for y := 0; y < b.Height; y++ {
+		a, c := b.Pix[y*b.Stride:][:b.Stride], other.Pix[y*other.Stride:][:other.Stride]
+		for i := range a {
+			if a[i] != c[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Clone returns a copy of the bitmap
+func (b *Bitmap) Clone() *Bitmap {
+	dup := *b
+	dup.Pix = append([]byte(nil), b.Pix...)
+	return &dup
+}
+
+// Gray returns the bitmap as an 8-bit image with lit dots white
+func (b *Bitmap) Gray() *image.Gray {
+	img := image.NewGray(b.Bounds())
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			if b.Get(x, y) {
+				img.Pix[y*img.Stride+x] = 0xFF
+			}
+		}
+	}
+	return img
+}
+
+// clearPadding zeroes the bits past Width in each row
+func (b *Bitmap) clearPadding() {
+	if b.Width%8 == 0 {
+		return
+	}
+	mask := byte(0xFF << uint(8-b.Width%8))
+	for y := 0; y < b.Height; y++ {
+		b.Pix[y*b.Stride+b.Stride-1] &= mask
+	}
+}
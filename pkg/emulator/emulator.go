@@ -0,0 +1,221 @@
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/harperreed/goflipdot/internal/packet"
+)
+
+var (
+	ErrChecksum       = packet.ErrChecksum
+	ErrMalformedFrame = packet.ErrMalformedPacket
+	ErrUnknownCommand = packet.ErrUnknownCommand
+)
+
+// signState is the emulated state of a single sign address
+type signState struct {
+	width  int
+	height int
+	dots   *image.Gray
+}
+
+// Emulator receives the byte stream sent to a bus of Hanover signs and keeps
+// the dot state of every address it sees. It is safe for concurrent use.
+type Emulator struct {
+	mu            sync.Mutex
+	defaultHeight int
+	signs         map[int]*signState
+	testMode      bool
+	buf           []byte
+	onUpdate      func(address int)
+	onError       func(err error)
+}
+
+// New creates an Emulator. Signs that have not been added with AddSign are
+// created on their first image, using defaultHeight rows and as many columns
+// as the image carries.
+func New(defaultHeight int) *Emulator {
+	return &Emulator{
+		defaultHeight: defaultHeight,
+		signs:         make(map[int]*signState),
+	}
+}
+
+// AddSign declares the geometry of the sign at address
+func (e *Emulator) AddSign(address, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return errors.New("width and height must be positive")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, exists := e.signs[address]; exists {
+		return fmt.Errorf("sign with address %d already exists", address)
+	}
+	e.signs[address] = newSign(width, height)
+	return nil
+}
+
+// OnUpdate registers fn to be called after an image is applied to a sign
+func (e *Emulator) OnUpdate(fn func(address int)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onUpdate = fn
+}
+
+// OnError registers fn to be called for every frame that is rejected
+func (e *Emulator) OnError(fn func(err error)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onError = fn
+}
+
+// Write feeds raw bus bytes into the emulator. Partial frames are buffered
+// until the rest arrives, so Write never fails.
+func (e *Emulator) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	e.buf = append(e.buf, p...)
+	var updated []int
+	var errs []error
+	for {
+		frame, ok := e.nextFrame()
+		if !ok {
+			break
+		}
+		address, err := e.apply(frame)
+		if err != nil {
+			errs = append(errs, err)
+		} else if address >= 0 {
+			updated = append(updated, address)
+		}
+	}
+	onUpdate, onError := e.onUpdate, e.onError
+	e.mu.Unlock()
+
+	if onError != nil {
+		for _, err := range errs {
+			onError(err)
+		}
+	}
+	if onUpdate != nil {
+		for _, address := range updated {
+			onUpdate(address)
+		}
+	}
+	return len(p), nil
+}
+
+// Serve feeds everything read from r into the emulator until r returns an error
+func (e *Emulator) Serve(r io.Reader) error {
+	_, err := io.Copy(e, r)
+	return err
+}
+
+// Addresses returns the addresses of all known signs in ascending order
+func (e *Emulator) Addresses() []int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	addresses := make([]int, 0, len(e.signs))
+	for address := range e.signs {
+		addresses = append(addresses, address)
+	}
+	sort.Ints(addresses)
+	return addresses
+}
+
+// Image returns a copy of the current dots of the sign at address, with lit
+// dots set to white
+func (e *Emulator) Image(address int) (*image.Gray, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s, ok := e.signs[address]
+	if !ok {
+		return nil, false
+	}
+	img := image.NewGray(s.dots.Rect)
+	copy(img.Pix, s.dots.Pix)
+	return img, true
+}
+
+// TestMode reports whether the signs are running their test sequence
+func (e *Emulator) TestMode() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.testMode
+}
+
+// nextFrame removes the next complete frame from the buffer, discarding any
+// noise before its start byte
+func (e *Emulator) nextFrame() ([]byte, bool) {
+	advance, frame, _ := packet.SplitFrames(e.buf, false)
+	e.buf = e.buf[advance:]
+	if frame == nil {
+		return nil, false
+	}
+	return frame, true
+}
+
+// apply updates the emulator state from frame. It returns the address of the
+// sign that changed, or -1 for broadcast commands.
+func (e *Emulator) apply(b []byte) (int, error) {
+	frame, err := packet.ParseFrame(b)
+	if err != nil {
+		return -1, err
+	}
+
+	height := e.defaultHeight
+	s, known := e.signs[frame.Address]
+	if known {
+		height = s.height
+	}
+	pkt, err := frame.Packet(height)
+	if err != nil {
+		return -1, err
+	}
+
+	switch p := pkt.(type) {
+	case packet.TestSignsStartPacket:
+		e.testMode = true
+		return -1, nil
+	case packet.TestSignsStopPacket:
+		e.testMode = false
+		return -1, nil
+	case packet.ImagePacket:
+		width := p.Image.Bounds().Dx()
+		if !known {
+			s = newSign(width, height)
+			e.signs[p.Address] = s
+		}
+		if width != s.width {
+			return -1, fmt.Errorf("%w: %d columns for %dx%d sign", ErrMalformedFrame, width, s.width, s.height)
+		}
+		copy(s.dots.Pix, p.Image.Pix)
+		return p.Address, nil
+	default:
+		return -1, fmt.Errorf("%w: %T", ErrUnknownCommand, pkt)
+	}
+}
+
+func newSign(width, height int) *signState {
+	return &signState{
+		width:  width,
+		height: height,
+		dots:   image.NewGray(image.Rect(0, 0, width, height)),
+	}
+}
+
+// ServePTY opens a pseudo-terminal and feeds everything written to it into the
+// emulator in the background. Point a controller at the returned PTY's Name
+// and close the PTY to stop serving.
+func (e *Emulator) ServePTY() (*PTY, error) {
+	pty, err := OpenPTY()
+	if err != nil {
+		return nil, err
+	}
+	go e.Serve(pty)
+	return pty, nil
+}
@@ -0,0 +1,82 @@
+//go:build linux
+
+package emulator
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// PTY is a pseudo-terminal that stands in for a serial port. Clients open
+// Name as if it were a USB-serial adapter; the emulator reads the master side.
+type PTY struct {
+	Name   string
+	master *os.File
+	slave  *os.File
+}
+
+// OpenPTY allocates a new pseudo-terminal in raw mode
+func OpenPTY() (*PTY, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open /dev/ptmx: %w", err)
+	}
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to unlock pty: %w", err)
+	}
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to get pty number: %w", err)
+	}
+	name := fmt.Sprintf("/dev/pts/%d", n)
+
+	// Hold the slave open so reads on the master do not fail while no client
+	// is connected, and so the line discipline stays raw between clients.
+	slave, err := os.OpenFile(name, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	if err := makeRaw(int(slave.Fd())); err != nil {
+		slave.Close()
+		master.Close()
+		return nil, fmt.Errorf("failed to set raw mode: %w", err)
+	}
+	return &PTY{Name: name, master: master, slave: slave}, nil
+}
+
+// Read reads bytes written by the client
+func (p *PTY) Read(b []byte) (int, error) {
+	return p.master.Read(b)
+}
+
+// Write sends bytes to the client
+func (p *PTY) Write(b []byte) (int, error) {
+	return p.master.Write(b)
+}
+
+// Close releases the pseudo-terminal
+func (p *PTY) Close() error {
+	p.slave.Close()
+	return p.master.Close()
+}
+
+func makeRaw(fd int) error {
+	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return err
+	}
+	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	t.Oflag &^= unix.OPOST
+	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	t.Cflag &^= unix.CSIZE | unix.PARENB
+	t.Cflag |= unix.CS8
+	t.Cc[unix.VMIN] = 1
+	t.Cc[unix.VTIME] = 0
+	return unix.IoctlSetTermios(fd, unix.TCSETS, t)
+}
@@ -0,0 +1,33 @@
+//go:build !linux
+
+package emulator
+
+import (
+	"errors"
+)
+
+// PTY is a pseudo-terminal that stands in for a serial port. It is only
+// available on Linux.
+type PTY struct {
+	Name string
+}
+
+// OpenPTY is not supported on this platform
+func OpenPTY() (*PTY, error) {
+	return nil, errors.New("pty emulation is only supported on linux")
+}
+
+// Read is not supported on this platform
+func (p *PTY) Read(b []byte) (int, error) {
+	return 0, errors.New("pty emulation is only supported on linux")
+}
+
+// Write is not supported on this platform
+func (p *PTY) Write(b []byte) (int, error) {
+	return 0, errors.New("pty emulation is only supported on linux")
+}
+
+// Close is not supported on this platform
+func (p *PTY) Close() error {
+	return nil
+}
@@ -0,0 +1,197 @@
+package font
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidFont = errors.New("invalid font")
+)
+
+// bdfChar is a glyph as described in a BDF file, before it is placed in a
+// font-height cell
+type bdfChar struct {
+	encoding   int
+	advance    int
+	w, h       int
+	xoff, yoff int
+	rows       [][]byte
+}
+
+// LoadBDF reads a font in Glyph Bitmap Distribution Format. Encodings are
+// taken as Unicode code points, which holds for ISO10646 and ISO8859-1 fonts.
+// Each glyph is placed on a cell as tall as the font's ascent plus descent and
+// as wide as its advance, so the font's Tracking is zero.
+func LoadBDF(r io.Reader) (*Font, error) {
+	s := bufio.NewScanner(r)
+	var (
+		name                    string
+		ascent, descent         int
+		haveAscent, haveDescent bool
+		bboxH, bboxYoff         int
+		defaultChar             = -1
+		chars                   []bdfChar
+		cur                     *bdfChar
+		inBitmap                bool
+		sawStart                bool
+	)
+
+	for lineNo := 1; s.Scan(); lineNo++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		bad := func(err error) error {
+			return fmt.Errorf("%w: line %d: %v", ErrInvalidFont, lineNo, err)
+		}
+
+		if inBitmap {
+			if fields[0] == "ENDCHAR" {
+				chars = append(chars, *cur)
+				cur, inBitmap = nil, false
+				continue
+			}
+			row, err := hex.DecodeString(fields[0])
+			if err != nil {
+				return nil, bad(err)
+			}
+			cur.rows = append(cur.rows, row)
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "STARTFONT":
+			sawStart = true
+		case "FONT":
+			name = strings.Join(fields[1:], " ")
+		case "FONTBOUNDINGBOX":
+			var v []int
+			if v, err = atois(fields[1:], 4); err == nil {
+				bboxH, bboxYoff = v[1], v[3]
+			}
+		case "FONT_ASCENT":
+			var v []int
+			if v, err = atois(fields[1:], 1); err == nil {
+				ascent, haveAscent = v[0], true
+			}
+		case "FONT_DESCENT":
+			var v []int
+			if v, err = atois(fields[1:], 1); err == nil {
+				descent, haveDescent = v[0], true
+			}
+		case "DEFAULT_CHAR":
+			var v []int
+			if v, err = atois(fields[1:], 1); err == nil {
+				defaultChar = v[0]
+			}
+		case "STARTCHAR":
+			cur = &bdfChar{encoding: -1}
+		case "ENCODING":
+			var v []int
+			if cur == nil {
+				err = errors.New("ENCODING outside STARTCHAR")
+			} else if v, err = atois(fields[1:], 1); err == nil {
+				cur.encoding = v[0]
+			}
+		case "DWIDTH":
+			var v []int
+			if cur == nil {
+				err = errors.New("DWIDTH outside STARTCHAR")
+			} else if v, err = atois(fields[1:], 1); err == nil {
+				cur.advance = v[0]
+			}
+		case "BBX":
+			var v []int
+			if cur == nil {
+				err = errors.New("BBX outside STARTCHAR")
+			} else if v, err = atois(fields[1:], 4); err == nil {
+				cur.w, cur.h, cur.xoff, cur.yoff = v[0], v[1], v[2], v[3]
+			}
+		case "BITMAP":
+			if cur == nil {
+				err = errors.New("BITMAP outside STARTCHAR")
+			}
+			inBitmap = true
+		}
+		if err != nil {
+			return nil, bad(err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if !sawStart {
+		return nil, fmt.Errorf("%w: missing STARTFONT", ErrInvalidFont)
+	}
+	if !haveAscent {
+		ascent = bboxH + bboxYoff
+	}
+	if !haveDescent {
+		descent = -bboxYoff
+	}
+	height := ascent + descent
+	if height <= 0 {
+		return nil, fmt.Errorf("%w: font height %d", ErrInvalidFont, height)
+	}
+
+	f := NewFont(name, height)
+	f.Tracking = 0
+	f.LineGap = 0
+	for _, c := range chars {
+		if c.encoding < 0 {
+			continue
+		}
+		width := c.advance
+		if width <= 0 {
+			width = c.xoff + c.w
+		}
+		g := NewGlyph(width, height)
+		top := ascent - (c.h + c.yoff)
+		for y, row := range c.rows {
+			for x := 0; x < c.w && x/8 < len(row); x++ {
+				if row[x/8]&(0x80>>uint(x%8)) != 0 {
+					g.Set(c.xoff+x, top+y, true)
+				}
+			}
+		}
+		f.AddGlyph(rune(c.encoding), g)
+	}
+	if g, ok := f.glyphs[rune(defaultChar)]; ok {
+		f.SetFallback(g)
+	} else {
+		f.setDefaultFallback()
+	}
+	return f, nil
+}
+
+// setDefaultFallback uses the replacement character or '?' as the fallback
+// glyph, if the font has either
+func (f *Font) setDefaultFallback() {
+	for _, r := range []rune{fallbackRune, '?'} {
+		if g, ok := f.glyphs[r]; ok {
+			f.SetFallback(g)
+			return
+		}
+	}
+}
+
+func atois(fields []string, n int) ([]int, error) {
+	if len(fields) < n {
+		return nil, fmt.Errorf("expected %d values, got %d", n, len(fields))
+	}
+	v := make([]int, n)
+	for i := range v {
+		var err error
+		if v[i], err = strconv.Atoi(fields[i]); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
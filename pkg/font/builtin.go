@@ -0,0 +1,183 @@
+package font
+
+// Built-in fonts for the common Hanover panel heights, each drawn by hand for
+// its height
+var (
+	// Font5x7 fills a 7-row panel, or two lines on a 16-row panel
+	Font5x7 = parseFont("5x7", 7, glyphs5x7, kerning)
+	// Font9x16 fills a 16-row panel. Capitals are 12 rows tall, leaving 4
+	// rows for descenders.
+	Font9x16 = parseFont("9x16", 16, glyphs9x16, kerning)
+	// Font9x19 fills a 19-row panel. Capitals are 15 rows tall, leaving 4
+	// rows for descenders.
+	Font9x19 = parseFont("9x19", 19, glyphs9x19, kerning)
+)
+
+var builtins = []*Font{Font9x19, Font9x16, Font5x7}
+
+// ForHeight returns the tallest built-in font no taller than rows, or the
+// smallest built-in font if none fit
+func ForHeight(rows int) *Font {
+	for _, f := range builtins {
+		if f.Height <= rows {
+			return f
+		}
+	}
+	return builtins[len(builtins)-1]
+}
+
+// parseFont builds a font from rows of '#' (on) and '.' (off). Blank columns
+// at the edges of a glyph are trimmed so the font is proportional; glyphs
+// with no dots keep their drawn width.
+func parseFont(name string, height int, art map[rune][]string, kerning map[[2]rune]int) *Font {
+	f := NewFont(name, height)
+	for r, rows := range art {
+		f.AddGlyph(r, parseGlyph(rows, height))
+	}
+	for pair, adjust := range kerning {
+		f.Kerning[pair] = adjust
+	}
+	f.SetFallback(f.glyphs[fallbackRune])
+	return f
+}
+
+func parseGlyph(rows []string, height int) *Glyph {
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	left, right := width, -1
+	for _, row := range rows {
+		for x := 0; x < len(row); x++ {
+			if row[x] == '#' {
+				left = min(left, x)
+				right = max(right, x)
+			}
+		}
+	}
+	if right < 0 {
+		return NewGlyph(width, height)
+	}
+
+	g := NewGlyph(right-left+1, height)
+	for y, row := range rows {
+		for x := left; x <= right && x < len(row); x++ {
+			g.Set(x-left, y, row[x] == '#')
+		}
+	}
+	return g
+}
+
+const fallbackRune = '�'
+
+// kerning holds the pairs the built-in fonts tighten by a column
+var kerning = map[[2]rune]int{
+	{'T', 'a'}: -1, {'T', 'c'}: -1, {'T', 'e'}: -1, {'T', 'o'}: -1,
+	{'T', 's'}: -1, {'T', '.'}: -1, {'T', ','}: -1, {'F', '.'}: -1,
+	{'F', ','}: -1, {'P', '.'}: -1, {'P', ','}: -1, {'L', 'T'}: -1,
+	{'L', 'Y'}: -1, {'Y', 'o'}: -1, {'Y', 'a'}: -1, {'Y', '.'}: -1,
+	{'r', '.'}: -1, {'r', ','}: -1,
+}
+
+var glyphs5x7 = map[rune][]string{
+	' ':  {"...", "...", "...", "...", "...", "...", "..."},
+	'!':  {"#", "#", "#", "#", "#", ".", "#"},
+	'"':  {"#.#", "#.#", "...", "...", "...", "...", "..."},
+	'#':  {".#.#.", ".#.#.", "#####", ".#.#.", "#####", ".#.#.", ".#.#."},
+	'$':  {"..#..", ".####", "#.#..", ".###.", "..#.#", "####.", "..#.."},
+	'%':  {"##...", "##..#", "...#.", "..#..", ".#...", "#..##", "...##"},
+	'&':  {".##..", "#..#.", "#.#..", ".#...", "#.#.#", "#..#.", ".##.#"},
+	'\'': {"#", "#", ".", ".", ".", ".", "."},
+	'(':  {"..#", ".#.", "#..", "#..", "#..", ".#.", "..#"},
+	')':  {"#..", ".#.", "..#", "..#", "..#", ".#.", "#.."},
+	'*':  {".....", "..#..", "#.#.#", ".###.", "#.#.#", "..#..", "....."},
+	'+':  {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+	',':  {"..", "..", "..", "..", ".#", ".#", "#."},
+	'-':  {"....", "....", "....", "####", "....", "....", "...."},
+	'.':  {".", ".", ".", ".", ".", ".", "#"},
+	'/':  {".....", "....#", "...#.", "..#..", ".#...", "#....", "....."},
+	'0':  {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1':  {".#.", "##.", ".#.", ".#.", ".#.", ".#.", "###"},
+	'2':  {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3':  {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4':  {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5':  {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6':  {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7':  {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8':  {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9':  {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	':':  {".", ".", "#", ".", ".", "#", "."},
+	';':  {"..", "..", ".#", "..", ".#", ".#", "#."},
+	'<':  {"...#", "..#.", ".#..", "#...", ".#..", "..#.", "...#"},
+	'=':  {".....", ".....", "#####", ".....", "#####", ".....", "....."},
+	'>':  {"#...", ".#..", "..#.", "...#", "..#.", ".#..", "#..."},
+	'?':  {".###.", "#...#", "....#", "...#.", "..#..", ".....", "..#.."},
+	'@':  {".###.", "#...#", "....#", ".##.#", "#.#.#", "#.#.#", ".###."},
+	'A':  {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'B':  {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+	'C':  {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+	'D':  {"###..", "#..#.", "#...#", "#...#", "#...#", "#..#.", "###.."},
+	'E':  {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'F':  {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+	'G':  {".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####"},
+	'H':  {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'I':  {"###", ".#.", ".#.", ".#.", ".#.", ".#.", "###"},
+	'J':  {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+	'K':  {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+	'L':  {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+	'M':  {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+	'N':  {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"},
+	'O':  {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'P':  {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+	'Q':  {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
+	'R':  {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+	'S':  {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
+	'T':  {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'U':  {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'V':  {"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+	'W':  {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."},
+	'X':  {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"},
+	'Y':  {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+	'Z':  {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
+	'[':  {"###", "#..", "#..", "#..", "#..", "#..", "###"},
+	'\\': {".....", "#....", ".#...", "..#..", "...#.", "....#", "....."},
+	']':  {"###", "..#", "..#", "..#", "..#", "..#", "###"},
+	'^':  {"..#..", ".#.#.", "#...#", ".....", ".....", ".....", "....."},
+	'_':  {".....", ".....", ".....", ".....", ".....", ".....", "#####"},
+	'`':  {"#.", ".#", "..", "..", "..", "..", ".."},
+	'a':  {".....", ".....", ".###.", "....#", ".####", "#...#", ".####"},
+	'b':  {"#....", "#....", "#.##.", "##..#", "#...#", "#...#", "####."},
+	'c':  {"....", "....", ".###", "#...", "#...", "#...", ".###"},
+	'd':  {"....#", "....#", ".##.#", "#..##", "#...#", "#...#", ".####"},
+	'e':  {".....", ".....", ".###.", "#...#", "#####", "#....", ".###."},
+	'f':  {"..##", ".#..", "####", ".#..", ".#..", ".#..", ".#.."},
+	'g':  {".....", ".....", ".####", "#...#", ".####", "....#", ".###."},
+	'h':  {"#....", "#....", "#.##.", "##..#", "#...#", "#...#", "#...#"},
+	'i':  {"#", ".", "#", "#", "#", "#", "#"},
+	'j':  {"..#", "...", "..#", "..#", "..#", "#.#", ".#."},
+	'k':  {"#...", "#...", "#..#", "#.#.", "##..", "#.#.", "#..#"},
+	'l':  {"##.", ".#.", ".#.", ".#.", ".#.", ".#.", "###"},
+	'm':  {".....", ".....", "##.#.", "#.#.#", "#.#.#", "#.#.#", "#.#.#"},
+	'n':  {"....", "....", "###.", "#..#", "#..#", "#..#", "#..#"},
+	'o':  {".....", ".....", ".###.", "#...#", "#...#", "#...#", ".###."},
+	'p':  {"....", "....", "###.", "#..#", "###.", "#...", "#..."},
+	'q':  {"....", "....", ".###", "#..#", ".###", "...#", "...#"},
+	'r':  {"....", "....", "#.##", "##..", "#...", "#...", "#..."},
+	's':  {"....", "....", ".###", "#...", ".##.", "...#", "###."},
+	't':  {".#..", ".#..", "####", ".#..", ".#..", ".#..", "..##"},
+	'u':  {"....", "....", "#..#", "#..#", "#..#", "#..#", ".###"},
+	'v':  {".....", ".....", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+	'w':  {".....", ".....", "#...#", "#...#", "#.#.#", "#.#.#", ".#.#."},
+	'x':  {".....", ".....", "#...#", ".#.#.", "..#..", ".#.#.", "#...#"},
+	'y':  {"....", "....", "#..#", "#..#", ".###", "...#", ".##."},
+	'z':  {".....", ".....", "#####", "...#.", "..#..", ".#...", "#####"},
+	'{':  {"..#", ".#.", ".#.", "#..", ".#.", ".#.", "..#"},
+	'|':  {"#", "#", "#", "#", "#", "#", "#"},
+	'}':  {"#..", ".#.", ".#.", "..#", ".#.", ".#.", "#.."},
+	'~':  {".....", ".....", ".#...", "#.#.#", "...#.", ".....", "....."},
+	'°':  {".#.", "#.#", ".#.", "...", "...", "...", "..."},
+
+	fallbackRune: {"#####", "#...#", "#...#", "#...#", "#...#", "#...#", "#####"},
+}
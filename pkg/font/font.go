@@ -0,0 +1,226 @@
+// Package font renders text onto flipdot canvases with bitmap fonts. Fonts
+// can be loaded from BDF and PSF files; built-in fonts drawn for 7, 16 and
+// 19-row panels are included.
+package font
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+var (
+	ErrOverflow = errors.New("text does not fit on the canvas")
+)
+
+// Glyph is the bitmap of a single character. Every glyph in a font is as tall
+// as the font; Width varies for proportional fonts.
+type Glyph struct {
+	Width  int
+	Height int
+	dots   []bool
+}
+
+// NewGlyph creates an empty glyph
+func NewGlyph(width, height int) *Glyph {
+	return &Glyph{
+		Width:  width,
+		Height: height,
+		dots:   make([]bool, width*height),
+	}
+}
+
+// At reports whether the dot at (x, y) is set
+func (g *Glyph) At(x, y int) bool {
+	if x < 0 || y < 0 || x >= g.Width || y >= g.Height {
+		return false
+	}
+	return g.dots[y*g.Width+x]
+}
+
+// Set sets or clears the dot at (x, y)
+func (g *Glyph) Set(x, y int, on bool) {
+	if x < 0 || y < 0 || x >= g.Width || y >= g.Height {
+		return
+	}
+	g.dots[y*g.Width+x] = on
+}
+
+// Font is a set of glyphs sharing a height
+type Font struct {
+	Name   string
+	Height int
+	// Tracking is the number of blank columns between glyphs
+	Tracking int
+	// LineGap is the number of blank rows between lines
+	LineGap int
+	// Kerning adjusts the space between specific pairs of glyphs
+	Kerning map[[2]rune]int
+
+	glyphs   map[rune]*Glyph
+	fallback *Glyph
+}
+
+// NewFont creates an empty font
+func NewFont(name string, height int) *Font {
+	return &Font{
+		Name:     name,
+		Height:   height,
+		Tracking: 1,
+		LineGap:  1,
+		Kerning:  make(map[[2]rune]int),
+		glyphs:   make(map[rune]*Glyph),
+	}
+}
+
+// AddGlyph adds or replaces the glyph for r
+func (f *Font) AddGlyph(r rune, g *Glyph) {
+	f.glyphs[r] = g
+}
+
+// SetFallback sets the glyph drawn for runes the font does not cover
+func (f *Font) SetFallback(g *Glyph) {
+	f.fallback = g
+}
+
+// Glyph returns the glyph for r, or the fallback glyph if the font does not
+// cover r. ok reports whether r itself was found.
+func (f *Font) Glyph(r rune) (g *Glyph, ok bool) {
+	if g, ok := f.glyphs[r]; ok {
+		return g, true
+	}
+	return f.fallback, false
+}
+
+// Measure returns the size of text set in the font. Lines are separated by
+// newlines.
+func (f *Font) Measure(text string) image.Point {
+	lines := strings.Split(text, "\n")
+	width := 0
+	for _, line := range lines {
+		if w := f.lineWidth(line); w > width {
+			width = w
+		}
+	}
+	height := len(lines)*f.Height + (len(lines)-1)*f.LineGap
+	return image.Pt(width, height)
+}
+
+func (f *Font) lineWidth(line string) int {
+	width := 0
+	var prev rune
+	for i, r := range []rune(line) {
+		g, _ := f.Glyph(r)
+		if g == nil {
+			continue
+		}
+		if i > 0 {
+			width += f.Tracking + f.Kerning[[2]rune{prev, r}]
+		}
+		width += g.Width
+		prev = r
+	}
+	return width
+}
+
+// HAlign is horizontal text alignment
+type HAlign int
+
+const (
+	AlignLeft HAlign = iota
+	AlignCenter
+	AlignRight
+)
+
+// VAlign is vertical text alignment
+type VAlign int
+
+const (
+	AlignTop VAlign = iota
+	AlignMiddle
+	AlignBottom
+)
+
+// Options controls how DrawText places text
+type Options struct {
+	// Font defaults to the largest built-in font that fits the canvas height
+	Font   *Font
+	HAlign HAlign
+	VAlign VAlign
+	// Color defaults to white, which lights the dots on a sign
+	Color color.Color
+}
+
+// DrawText draws text onto dst, aligning each line within dst's bounds. It
+// returns the rectangle covered by the text. If the text does not fit, the
+// visible part is drawn and ErrOverflow is returned.
+func DrawText(dst draw.Image, text string, opts Options) (image.Rectangle, error) {
+	bounds := dst.Bounds()
+	f := opts.Font
+	if f == nil {
+		f = ForHeight(bounds.Dy())
+	}
+	c := opts.Color
+	if c == nil {
+		c = color.White
+	}
+
+	size := f.Measure(text)
+	var y int
+	switch opts.VAlign {
+	case AlignMiddle:
+		y = bounds.Min.Y + (bounds.Dy()-size.Y)/2
+	case AlignBottom:
+		y = bounds.Max.Y - size.Y
+	default:
+		y = bounds.Min.Y
+	}
+
+	covered := image.Rectangle{}
+	for _, line := range strings.Split(text, "\n") {
+		width := f.lineWidth(line)
+		var x int
+		switch opts.HAlign {
+		case AlignCenter:
+			x = bounds.Min.X + (bounds.Dx()-width)/2
+		case AlignRight:
+			x = bounds.Max.X - width
+		default:
+			x = bounds.Min.X
+		}
+		covered = covered.Union(image.Rect(x, y, x+width, y+f.Height))
+		drawLine(dst, f, line, x, y, c)
+		y += f.Height + f.LineGap
+	}
+
+	if !covered.In(bounds) {
+		return covered, ErrOverflow
+	}
+	return covered, nil
+}
+
+func drawLine(dst draw.Image, f *Font, line string, x, y int, c color.Color) {
+	bounds := dst.Bounds()
+	var prev rune
+	for i, r := range []rune(line) {
+		g, _ := f.Glyph(r)
+		if g == nil {
+			continue
+		}
+		if i > 0 {
+			x += f.Tracking + f.Kerning[[2]rune{prev, r}]
+		}
+		for gy := 0; gy < g.Height; gy++ {
+			for gx := 0; gx < g.Width; gx++ {
+				p := image.Pt(x+gx, y+gy)
+				if g.At(gx, gy) && p.In(bounds) {
+					dst.Set(p.X, p.Y, c)
+				}
+			}
+		}
+		x += g.Width
+		prev = r
+	}
+}
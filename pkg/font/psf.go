@@ -0,0 +1,201 @@
+package font
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	psf1Magic = []byte{0x36, 0x04}
+	psf2Magic = []byte{0x72, 0xb5, 0x4a, 0x86}
+)
+
+const (
+	psf1Mode512    = 0x01
+	psf1ModeHasTab = 0x02
+	psf1ModeSeq    = 0x04
+
+	psf2HasUnicodeTable = 0x01
+)
+
+// LoadPSF reads a PC Screen Font, version 1 or 2. If the font carries a
+// Unicode table its mappings are used; otherwise each glyph's index is taken
+// as its code point. PSF glyphs include their own spacing, so the font's
+// Tracking is zero.
+func LoadPSF(r io.Reader) (*Font, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(data, psf2Magic):
+		return loadPSF2(data)
+	case bytes.HasPrefix(data, psf1Magic):
+		return loadPSF1(data)
+	}
+	return nil, fmt.Errorf("%w: not a PSF file", ErrInvalidFont)
+}
+
+// LoadFile reads a BDF or PSF font, detecting the format from its contents
+func LoadFile(path string) (*Font, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	head, _ := br.Peek(len(psf2Magic))
+	var f *Font
+	if bytes.HasPrefix(head, psf1Magic) || bytes.HasPrefix(head, psf2Magic) {
+		f, err = LoadPSF(br)
+	} else {
+		f, err = LoadBDF(br)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	if f.Name == "" {
+		f.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return f, nil
+}
+
+func loadPSF1(data []byte) (*Font, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("%w: short PSF1 header", ErrInvalidFont)
+	}
+	mode, height := data[2], int(data[3])
+	count := 256
+	if mode&psf1Mode512 != 0 {
+		count = 512
+	}
+	glyphs := data[4:]
+	if height == 0 || len(glyphs) < count*height {
+		return nil, fmt.Errorf("%w: truncated PSF1 glyphs", ErrInvalidFont)
+	}
+
+	f := psfFont(height)
+	bitmaps := make([]*Glyph, count)
+	for i := range bitmaps {
+		bitmaps[i] = psfGlyph(glyphs[i*height:(i+1)*height], 8, height)
+	}
+
+	if mode&(psf1ModeHasTab|psf1ModeSeq) == 0 {
+		addByIndex(f, bitmaps)
+		return f, nil
+	}
+	table := glyphs[count*height:]
+	for i := 0; i < count; i++ {
+		inSequence := false
+		for {
+			if len(table) < 2 {
+				return nil, fmt.Errorf("%w: truncated PSF1 unicode table", ErrInvalidFont)
+			}
+			v := binary.LittleEndian.Uint16(table)
+			table = table[2:]
+			if v == 0xFFFF {
+				break
+			}
+			if v == 0xFFFE {
+				// Multi-rune sequences cannot be drawn from single runes
+				inSequence = true
+				continue
+			}
+			if !inSequence {
+				f.AddGlyph(rune(v), bitmaps[i])
+			}
+		}
+	}
+	f.setDefaultFallback()
+	return f, nil
+}
+
+func loadPSF2(data []byte) (*Font, error) {
+	if len(data) < 32 {
+		return nil, fmt.Errorf("%w: short PSF2 header", ErrInvalidFont)
+	}
+	le := binary.LittleEndian
+	headerSize := int(le.Uint32(data[8:]))
+	flags := le.Uint32(data[12:])
+	count := int(le.Uint32(data[16:]))
+	charSize := int(le.Uint32(data[20:]))
+	height := int(le.Uint32(data[24:]))
+	width := int(le.Uint32(data[28:]))
+	if width == 0 || height == 0 || charSize < height*((width+7)/8) || headerSize < 32 {
+		return nil, fmt.Errorf("%w: bad PSF2 geometry", ErrInvalidFont)
+	}
+	end := headerSize + count*charSize
+	if end < headerSize || len(data) < end {
+		return nil, fmt.Errorf("%w: truncated PSF2 glyphs", ErrInvalidFont)
+	}
+
+	f := psfFont(height)
+	bitmaps := make([]*Glyph, count)
+	for i := range bitmaps {
+		start := headerSize + i*charSize
+		bitmaps[i] = psfGlyph(data[start:start+charSize], width, height)
+	}
+
+	if flags&psf2HasUnicodeTable == 0 {
+		addByIndex(f, bitmaps)
+		return f, nil
+	}
+	table := data[end:]
+	for i := 0; i < count; i++ {
+		entry := table
+		if n := bytes.IndexByte(table, 0xFF); n >= 0 {
+			entry, table = table[:n], table[n+1:]
+		} else {
+			table = nil
+		}
+		// Anything after 0xFE is a multi-rune sequence
+		if n := bytes.IndexByte(entry, 0xFE); n >= 0 {
+			entry = entry[:n]
+		}
+		for len(entry) > 0 {
+			r, size := utf8.DecodeRune(entry)
+			if r == utf8.RuneError && size <= 1 {
+				return nil, fmt.Errorf("%w: bad UTF-8 in PSF2 unicode table", ErrInvalidFont)
+			}
+			f.AddGlyph(r, bitmaps[i])
+			entry = entry[size:]
+		}
+	}
+	f.setDefaultFallback()
+	return f, nil
+}
+
+func psfFont(height int) *Font {
+	f := NewFont("", height)
+	f.Tracking = 0
+	f.LineGap = 0
+	return f
+}
+
+func psfGlyph(rows []byte, width, height int) *Glyph {
+	g := NewGlyph(width, height)
+	stride := (width + 7) / 8
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if rows[y*stride+x/8]&(0x80>>uint(x%8)) != 0 {
+				g.Set(x, y, true)
+			}
+		}
+	}
+	return g
+}
+
+func addByIndex(f *Font, bitmaps []*Glyph) {
+	for i, g := range bitmaps {
+		f.AddGlyph(rune(i), g)
+	}
+	f.setDefaultFallback()
+}
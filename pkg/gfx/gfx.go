@@ -0,0 +1,353 @@
+package gfx
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+)
+
+// MaxRadius is the largest radius Circle and Ellipse draw. Larger shapes are
+// skipped, which also keeps their arithmetic from overflowing.
+const MaxRadius = 1 << 20
+
+// Mode is how a shape changes the dots it covers
+type Mode int
+
+const (
+	// ModeSet lights dots
+	ModeSet Mode = iota
+	// ModeClear turns dots off
+	ModeClear
+	// ModeXOR flips dots, so drawing a shape twice restores the canvas
+	ModeXOR
+)
+
+// Painter draws shapes onto a 1-bit canvas. Any draw.Image works; dots count
+// as lit by bitmap.IsOn. Each shape changes every dot it covers exactly once,
+// so XOR drawing never cancels itself out where edges meet. Dots outside the
+// canvas are skipped.
+type Painter struct {
+	Dst  draw.Image
+	Mode Mode
+}
+
+// New creates a Painter that lights dots on dst
+func New(dst draw.Image) *Painter {
+	return &Painter{Dst: dst}
+}
+
+// shape gathers the dots of one shape, dropping those outside the canvas as
+// they are added. In XOR mode the dots are collected in a bitmap and flipped
+// once by apply, so dots shared by several strokes flip only once; other
+// modes paint each dot straight away since painting it twice is harmless.
+type shape struct {
+	p      *Painter
+	bounds image.Rectangle
+	dots   *bitmap.Bitmap
+}
+
+func (p *Painter) newShape() *shape {
+	s := &shape{p: p, bounds: p.Dst.Bounds()}
+	if p.Mode == ModeXOR {
+		s.dots = bitmap.New(s.bounds.Dx(), s.bounds.Dy())
+	}
+	return s
+}
+
+func (s *shape) add(x, y int) {
+	if !image.Pt(x, y).In(s.bounds) {
+		return
+	}
+	if s.dots == nil {
+		s.p.paint(x, y)
+		return
+	}
+	s.dots.SetBit(x-s.bounds.Min.X, y-s.bounds.Min.Y, true)
+}
+
+// apply flips the dots collected in XOR mode
+func (s *shape) apply() {
+	if s.dots == nil {
+		return
+	}
+	for y := 0; y < s.dots.Height; y++ {
+		row := s.dots.Pix[y*s.dots.Stride:][:s.dots.Stride]
+		for i, v := range row {
+			if v == 0 {
+				continue
+			}
+			for x := i * 8; x < i*8+8 && x < s.dots.Width; x++ {
+				if s.dots.Get(x, y) {
+					s.p.paint(s.bounds.Min.X+x, s.bounds.Min.Y+y)
+				}
+			}
+		}
+	}
+}
+
+func (p *Painter) paint(x, y int) {
+	on := true
+	switch p.Mode {
+	case ModeClear:
+		on = false
+	case ModeXOR:
+		on = !p.get(x, y)
+	}
+	if b, ok := p.Dst.(*bitmap.Bitmap); ok {
+		b.SetBit(x, y, on)
+		return
+	}
+	if on {
+		p.Dst.Set(x, y, bitmap.On)
+	} else {
+		p.Dst.Set(x, y, bitmap.Off)
+	}
+}
+
+func (p *Painter) get(x, y int) bool {
+	if b, ok := p.Dst.(*bitmap.Bitmap); ok {
+		return b.Get(x, y)
+	}
+	return bitmap.IsOn(p.Dst.At(x, y))
+}
+
+// Dot changes a single dot
+func (p *Painter) Dot(x, y int) {
+	if image.Pt(x, y).In(p.Dst.Bounds()) {
+		p.paint(x, y)
+	}
+}
+
+// Line draws a line from (x0, y0) to (x1, y1), both ends included
+func (p *Painter) Line(x0, y0, x1, y1 int) {
+	s := p.newShape()
+	s.line(x0, y0, x1, y1)
+	s.apply()
+}
+
+// line adds the dots of a line using Bresenham's algorithm. Lines leaving
+// the canvas are clipped to it first, so only the visible part is traced.
+func (s *shape) line(x0, y0, x1, y1 int) {
+	a, b := image.Pt(x0, y0), image.Pt(x1, y1)
+	if !a.In(s.bounds) || !b.In(s.bounds) {
+		var ok bool
+		if a, b, ok = clipLine(a, b, s.bounds); !ok {
+			return
+		}
+		x0, y0, x1, y1 = a.X, a.Y, b.X, b.Y
+	}
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	e := dx + dy
+	for {
+		s.add(x0, y0)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// Rect draws the outline of r. Like image.Rectangle, r.Max is exclusive.
+func (p *Painter) Rect(r image.Rectangle) {
+	r = r.Canon()
+	if r.Empty() {
+		return
+	}
+	s := p.newShape()
+	// Only walk the part of each edge that can be on the canvas
+	c := r.Intersect(s.bounds)
+	for x := c.Min.X; x < c.Max.X; x++ {
+		s.add(x, r.Min.Y)
+		s.add(x, r.Max.Y-1)
+	}
+	for y := c.Min.Y; y < c.Max.Y; y++ {
+		s.add(r.Min.X, y)
+		s.add(r.Max.X-1, y)
+	}
+	s.apply()
+}
+
+// FillRect changes every dot in r
+func (p *Painter) FillRect(r image.Rectangle) {
+	r = r.Canon().Intersect(p.Dst.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p.paint(x, y)
+		}
+	}
+}
+
+// Circle draws a circle of radius r centred on (cx, cy)
+func (p *Painter) Circle(cx, cy, r int) {
+	if r < 0 || r > MaxRadius || !image.Rect(cx-r, cy-r, cx+r+1, cy+r+1).Overlaps(p.Dst.Bounds()) {
+		return
+	}
+	s := p.newShape()
+	// Midpoint circle algorithm, mirrored into all eight octants
+	x, y, e := r, 0, 1-r
+	for x >= y {
+		for _, pt := range [][2]int{{x, y}, {y, x}, {-y, x}, {-x, y}, {-x, -y}, {-y, -x}, {y, -x}, {x, -y}} {
+			s.add(cx+pt[0], cy+pt[1])
+		}
+		y++
+		if e < 0 {
+			e += 2*y + 1
+		} else {
+			x--
+			e += 2*(y-x) + 1
+		}
+	}
+	s.apply()
+}
+
+// Ellipse draws an axis-aligned ellipse with radii rx and ry centred on
+// (cx, cy)
+func (p *Painter) Ellipse(cx, cy, rx, ry int) {
+	if rx < 0 || ry < 0 || rx > MaxRadius || ry > MaxRadius ||
+		!image.Rect(cx-rx, cy-ry, cx+rx+1, cy+ry+1).Overlaps(p.Dst.Bounds()) {
+		return
+	}
+	s := p.newShape()
+	quad := func(x, y int) {
+		s.add(cx+x, cy+y)
+		s.add(cx-x, cy+y)
+		s.add(cx+x, cy-y)
+		s.add(cx-x, cy-y)
+	}
+
+	// Zingl's ellipse algorithm, working through one quadrant from the left
+	// end of the ellipse to the top and mirroring it into the others
+	a2, b2 := rx*rx, ry*ry
+	x, y := -rx, 0
+	e := x*(2*b2+x) + b2
+	for x <= 0 {
+		quad(-x, y)
+		e2 := 2 * e
+		if e2 >= (2*x+1)*b2 {
+			x++
+			e += (2*x + 1) * b2
+		}
+		if e2 <= (2*y+1)*a2 {
+			y++
+			e += (2*y + 1) * a2
+		}
+	}
+	// Very flat ellipses stop early; finish their tips
+	for y++; y <= ry; y++ {
+		quad(0, y)
+	}
+	s.apply()
+}
+
+// Polygon draws the closed outline through pts
+func (p *Painter) Polygon(pts ...image.Point) {
+	if len(pts) == 0 {
+		return
+	}
+	s := p.newShape()
+	for i, a := range pts {
+		b := pts[(i+1)%len(pts)]
+		s.line(a.X, a.Y, b.X, b.Y)
+	}
+	s.apply()
+}
+
+// FloodFill changes the region of dots connected to (x, y) horizontally or
+// vertically that are in the same state as it
+func (p *Painter) FloodFill(x, y int) {
+	bounds := p.Dst.Bounds()
+	if !image.Pt(x, y).In(bounds) {
+		return
+	}
+	target := p.get(x, y)
+	// Find the whole region before changing it, since painting may leave
+	// dots in the target state
+	region := bitmap.New(bounds.Dx(), bounds.Dy())
+	stack := []image.Point{image.Pt(x, y)}
+	for len(stack) > 0 {
+		pt := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		rx, ry := pt.X-bounds.Min.X, pt.Y-bounds.Min.Y
+		if !pt.In(bounds) || region.Get(rx, ry) || p.get(pt.X, pt.Y) != target {
+			continue
+		}
+		region.SetBit(rx, ry, true)
+		stack = append(stack,
+			image.Pt(pt.X+1, pt.Y), image.Pt(pt.X-1, pt.Y),
+			image.Pt(pt.X, pt.Y+1), image.Pt(pt.X, pt.Y-1))
+	}
+	for ry := 0; ry < region.Height; ry++ {
+		for rx := 0; rx < region.Width; rx++ {
+			if region.Get(rx, ry) {
+				p.paint(bounds.Min.X+rx, bounds.Min.Y+ry)
+			}
+		}
+	}
+}
+
+// clipLine clips the line from a to b to the dot centres of r using the
+// Liang-Barsky algorithm. ok is false if the line misses r.
+func clipLine(a, b image.Point, r image.Rectangle) (image.Point, image.Point, bool) {
+	x0, y0 := float64(a.X), float64(a.Y)
+	dx, dy := float64(b.X-a.X), float64(b.Y-a.Y)
+	t0, t1 := 0.0, 1.0
+	for _, edge := range [4][2]float64{
+		{-dx, x0 - float64(r.Min.X)},
+		{dx, float64(r.Max.X-1) - x0},
+		{-dy, y0 - float64(r.Min.Y)},
+		{dy, float64(r.Max.Y-1) - y0},
+	} {
+		p, q := edge[0], edge[1]
+		if p == 0 {
+			if q < 0 {
+				return a, b, false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+	}
+	if t0 > t1 {
+		return a, b, false
+	}
+	// Clamp as well as round in case of floating point error at the edges
+	clamp := func(t float64) image.Point {
+		pt := image.Pt(int(math.Round(x0+t*dx)), int(math.Round(y0+t*dy)))
+		pt.X = min(max(pt.X, r.Min.X), r.Max.X-1)
+		pt.Y = min(max(pt.Y, r.Min.Y), r.Max.Y-1)
+		return pt
+	}
+	return clamp(t0), clamp(t1), true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package goflipdot
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"time"
+
+	"github.com/harperreed/goflipdot/pkg/imageconv"
+)
+
+// defaultGIFDelay is used for frames with no delay, as browsers do
+const defaultGIFDelay = 100 * time.Millisecond
+
+// PlayGIF plays an animated GIF on a sign. Each frame is composited according
+// to its disposal method, converted to dots with opts and held for its delay.
+// Frames are never shown faster than the sign's frame rate limit set with
+// WithMaxFPS or SetMaxFPS. The GIF's loop count is honored; a GIF that loops
+// forever plays until ctx ends.
+func (c *Controller) PlayGIF(ctx context.Context, g *gif.GIF, signName string, opts imageconv.Options) error {
+	if len(g.Image) == 0 {
+		return fmt.Errorf("gif has no frames")
+	}
+	canvas, err := c.CreateImage(signName)
+	if err != nil {
+		return err
+	}
+	size := canvas.Bounds().Size()
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = g.Image[0].Bounds()
+	}
+
+	// LoopCount 0 loops forever, -1 plays once and n plays n+1 times
+	plays := g.LoopCount + 1
+	switch {
+	case g.LoopCount == 0:
+		plays = -1
+	case g.LoopCount < 0:
+		plays = 1
+	}
+	for play := 0; plays < 0 || play < plays; play++ {
+		screen := image.NewRGBA(bounds)
+		var previous *image.RGBA
+		for i, frame := range g.Image {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			disposal := byte(gif.DisposalNone)
+			if i < len(g.Disposal) {
+				disposal = g.Disposal[i]
+			}
+			if disposal == gif.DisposalPrevious {
+				previous = image.NewRGBA(bounds)
+				draw.Draw(previous, bounds, screen, bounds.Min, draw.Src)
+			}
+			draw.Draw(screen, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+			delay := defaultGIFDelay
+			if i < len(g.Delay) && g.Delay[i] > 0 {
+				delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+			}
+			deadline := time.Now().Add(delay)
+			img, err := imageconv.Convert(screen, size.X, size.Y, opts)
+			if err != nil {
+				return err
+			}
+			if err := c.DrawImageContext(ctx, img, signName); err != nil {
+				return err
+			}
+			// DrawImageContext waits for the sign's next slot, so a frame
+			// shorter than the limit is held until the sign is ready
+			if wait := time.Until(deadline); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+
+			switch disposal {
+			case gif.DisposalBackground:
+				draw.Draw(screen, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				screen = previous
+			}
+		}
+	}
+	return nil
+}
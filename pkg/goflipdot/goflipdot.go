@@ -1,11 +1,17 @@
 package goflipdot
 
 import (
+	"context"
 	"fmt"
 	"image"
+	"io"
+	"log/slog"
+	"time"
 
 	"github.com/harperreed/goflipdot/internal/controller"
 	"github.com/harperreed/goflipdot/internal/sign"
+	"github.com/harperreed/goflipdot/internal/transport"
+	"github.com/harperreed/goflipdot/pkg/bitmap"
 )
 
 // Controller represents the main interface for controlling Hanover flipdot displays
@@ -13,9 +19,125 @@ type Controller struct {
 	ctrl *controller.HanoverController
 }
 
-// NewController creates a new Controller
-func NewController(serialPort string) (*Controller, error) {
-	ctrl, err := controller.NewHanoverController(serialPort)
+// Option configures a Controller
+type Option = controller.Option
+
+// WithResponseTimeout sets how long commands wait for a response from the
+// signs. A zero duration disables reading responses.
+func WithResponseTimeout(d time.Duration) Option {
+	return controller.WithResponseTimeout(d)
+}
+
+var (
+	ErrClosed            = controller.ErrClosed
+	ErrDisconnected      = controller.ErrDisconnected
+	ErrNoResponse        = controller.ErrNoResponse
+	ErrMalformedResponse = controller.ErrMalformedResponse
+	ErrRejected          = controller.ErrRejected
+)
+
+// Response is what the signs sent back after a command
+type Response = controller.Response
+
+// ResponseKind classifies a Response
+type ResponseKind = controller.ResponseKind
+
+const (
+	ResponseNone    = controller.ResponseNone
+	ResponseAck     = controller.ResponseAck
+	ResponseError   = controller.ResponseError
+	ResponseUnknown = controller.ResponseUnknown
+)
+
+// ResponsePolicy decides which responses make a command fail
+type ResponsePolicy = controller.ResponsePolicy
+
+// WithResponsePolicy sets which responses make a command fail. By default
+// only error responses do; missing and malformed responses are returned
+// without an error.
+func WithResponsePolicy(p ResponsePolicy) Option {
+	return controller.WithResponsePolicy(p)
+}
+
+// ConnectionState describes whether the controller has a working transport
+type ConnectionState = controller.ConnectionState
+
+const (
+	StateConnected    = controller.StateConnected
+	StateDisconnected = controller.StateDisconnected
+)
+
+// WithReopen enables automatic reconnection. When a write fails the
+// transport is closed and open is retried with backoff until it succeeds or
+// the Controller is closed. Serial controllers reopen their port by default.
+func WithReopen(open func() (io.ReadWriteCloser, error)) Option {
+	return controller.WithReopen(func() (transport.Transport, error) {
+		port, err := open()
+		if err != nil {
+			return nil, err
+		}
+		return transport.New(port)
+	})
+}
+
+// WithBackoff sets the delay before the first reconnection attempt and the
+// limit it doubles up to on each failed attempt
+func WithBackoff(min, max time.Duration) Option {
+	return controller.WithBackoff(min, max)
+}
+
+// WithStateHandler registers fn to be called whenever the connection state
+// changes. err is the write error that caused a disconnection, or nil.
+func WithStateHandler(fn func(state ConnectionState, err error)) Option {
+	return controller.WithStateHandler(fn)
+}
+
+// WithMaxFPS limits how many frames per second are sent to each sign.
+// Frames drawn faster than this are coalesced so the sign always shows the
+// latest one. Zero, the default, sends every frame immediately.
+func WithMaxFPS(fps float64) Option {
+	return controller.WithMaxFPS(fps)
+}
+
+// WithLogger sends the controller's log output to l. Each packet is logged
+// at Debug level with its address, command, length and checksum. By default
+// nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return controller.WithLogger(l)
+}
+
+// Metrics receives events from a controller. metrics.Collector implements it
+// and serves the counts in Prometheus text format.
+type Metrics = controller.Metrics
+
+// WithMetrics reports the controller's frames, writes, read timeouts and
+// response checksum failures to m
+func WithMetrics(m Metrics) Option {
+	return controller.WithMetrics(m)
+}
+
+// DrawOption changes how a single image is drawn
+type DrawOption = controller.DrawOption
+
+// Force sends an image even if the sign already shows it, for example after
+// the sign has been power cycled
+func Force() DrawOption {
+	return controller.Force()
+}
+
+// FrameDiff lists the dots that would flip if an image were drawn
+type FrameDiff = controller.FrameDiff
+
+// NewController creates a new Controller that talks to signs over port, which
+// may be a serial adapter, pipe, socket or in-memory fake. If port is an
+// io.ReadWriteCloser it is closed by Close. Responses are not read unless
+// WithResponseTimeout is given.
+func NewController(port io.ReadWriter, opts ...Option) (*Controller, error) {
+	t, err := transport.New(port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create controller: %w", err)
+	}
+	ctrl, err := controller.NewHanoverController(t, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create controller: %w", err)
 	}
@@ -24,28 +146,175 @@ func NewController(serialPort string) (*Controller, error) {
 	}, nil
 }
 
-// AddSign adds a new sign to the controller
-func (c *Controller) AddSign(name string, address, width, height int) error {
-	s, err := sign.NewHanoverSign(address, width, height)
+// NewSerialController creates a new Controller on the serial port at path
+func NewSerialController(serialPort string, opts ...Option) (*Controller, error) {
+	ctrl, err := controller.NewSerialHanoverController(serialPort, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create controller: %w", err)
+	}
+	return &Controller{
+		ctrl: ctrl,
+	}, nil
+}
+
+// Rotation is a clockwise rotation in degrees applied when sending images
+type Rotation = sign.Rotation
+
+const (
+	Rotate0   = sign.Rotate0
+	Rotate90  = sign.Rotate90
+	Rotate180 = sign.Rotate180
+	Rotate270 = sign.Rotate270
+)
+
+// Orientation describes how a panel is mounted relative to the images drawn
+// for it. Mirroring is applied first, then rotation.
+type Orientation = sign.Orientation
+
+// Close stops all background work and closes the transport
+func (c *Controller) Close() error {
+	return c.ctrl.Close()
+}
+
+// State returns whether the controller currently has a working transport
+func (c *Controller) State() ConnectionState {
+	return c.ctrl.State()
+}
+
+// AddSign adds a new sign to the controller. Set flip for panels mounted
+// upside down.
+func (c *Controller) AddSign(name string, address, width, height int, flip bool) error {
+	s, err := sign.NewHanoverSign(address, width, height, flip)
 	if err != nil {
 		return fmt.Errorf("failed to create sign: %w", err)
 	}
 	return c.ctrl.AddSign(name, s)
 }
 
+// Model describes a Hanover panel in the sign model catalog
+type Model = sign.Model
+
+// ErrUnknownModel is returned for model names missing from the catalog
+var ErrUnknownModel = sign.ErrUnknownModel
+
+// DefaultRefreshRate is the frame rate limit of models registered without
+// one, and of every built-in model
+const DefaultRefreshRate = sign.DefaultRefreshRate
+
+// Models returns the sign model catalog sorted by name
+func Models() []Model {
+	return sign.Models()
+}
+
+// RegisterModel adds a model to the catalog, rejecting geometries that do not
+// fit in an image packet. A model without a RefreshRate gets
+// DefaultRefreshRate.
+func RegisterModel(m Model) error {
+	return sign.RegisterModel(m)
+}
+
+// AddSignModel adds a sign of a catalog model, such as "7000-28x19". The
+// sign's frame rate is limited to the model's recommended refresh rate;
+// SetMaxFPS changes it.
+func (c *Controller) AddSignModel(name string, address int, model string) error {
+	m, err := sign.LookupModel(model)
+	if err != nil {
+		return err
+	}
+	s, err := sign.NewHanoverSign(address, m.Columns, m.Rows, false)
+	if err != nil {
+		return fmt.Errorf("failed to create sign: %w", err)
+	}
+	if err := c.ctrl.AddSign(name, s); err != nil {
+		return err
+	}
+	return c.ctrl.SetMaxFPS(name, m.RefreshRate)
+}
+
+// SetOrientation sets how a sign is mounted. Images passed to DrawImage are
+// transformed to the panel's physical layout before sending, and CreateImage
+// returns images sized for the rotated sign.
+func (c *Controller) SetOrientation(signName string, o Orientation) error {
+	return c.ctrl.SetOrientation(signName, o)
+}
+
+// SetMaxFPS limits how many frames per second are sent to a sign, overriding
+// WithMaxFPS. Zero removes the limit.
+func (c *Controller) SetMaxFPS(signName string, fps float64) error {
+	return c.ctrl.SetMaxFPS(signName, fps)
+}
+
+// DroppedFrames returns how many frames drawn to a sign were replaced by a
+// newer frame before the sign was ready for them
+func (c *Controller) DroppedFrames(signName string) (uint64, error) {
+	return c.ctrl.DroppedFrames(signName)
+}
+
 // StartTestSigns starts the test sequence on all connected signs
 func (c *Controller) StartTestSigns() error {
 	return c.ctrl.StartTestSigns()
 }
 
+// StartTestSignsContext starts the test sequence on all connected signs,
+// giving up when ctx is cancelled or its deadline passes
+func (c *Controller) StartTestSignsContext(ctx context.Context) error {
+	return c.ctrl.StartTestSignsContext(ctx)
+}
+
+// StartTestSignsResponse starts the test sequence on all connected signs and
+// returns their response. Responses are only read with WithResponseTimeout,
+// which serial controllers set by default.
+func (c *Controller) StartTestSignsResponse(ctx context.Context) (Response, error) {
+	return c.ctrl.StartTestSignsResponse(ctx)
+}
+
 // StopTestSigns stops the test sequence on all connected signs
 func (c *Controller) StopTestSigns() error {
 	return c.ctrl.StopTestSigns()
 }
 
-// DrawImage sends an image to a specific sign
-func (c *Controller) DrawImage(img *image.Gray, signName string) error {
-	return c.ctrl.DrawImage(img, signName)
+// StopTestSignsContext stops the test sequence on all connected signs,
+// giving up when ctx is cancelled or its deadline passes
+func (c *Controller) StopTestSignsContext(ctx context.Context) error {
+	return c.ctrl.StopTestSignsContext(ctx)
+}
+
+// StopTestSignsResponse stops the test sequence on all connected signs and
+// returns their response
+func (c *Controller) StopTestSignsResponse(ctx context.Context) (Response, error) {
+	return c.ctrl.StopTestSignsResponse(ctx)
+}
+
+// DrawImage sends an image to a specific sign. Images identical to the one
+// the sign already shows are not resent unless Force is given.
+func (c *Controller) DrawImage(img *image.Gray, signName string, opts ...DrawOption) error {
+	return c.ctrl.DrawImage(img, signName, opts...)
+}
+
+// DrawImageContext sends an image to a specific sign, giving up when ctx is
+// cancelled or its deadline passes. With a frame rate limit it waits for the
+// sign to be ready, returning nil without sending if a newer frame is drawn
+// first.
+func (c *Controller) DrawImageContext(ctx context.Context, img *image.Gray, signName string, opts ...DrawOption) error {
+	return c.ctrl.DrawImageContext(ctx, img, signName, opts...)
+}
+
+// DrawBitmap sends a bitmap to a specific sign
+func (c *Controller) DrawBitmap(b *bitmap.Bitmap, signName string, opts ...DrawOption) error {
+	return c.ctrl.DrawBitmap(b, signName, opts...)
+}
+
+// DrawBitmapContext sends a bitmap to a specific sign, giving up when ctx is
+// cancelled or its deadline passes
+func (c *Controller) DrawBitmapContext(ctx context.Context, b *bitmap.Bitmap, signName string, opts ...DrawOption) error {
+	return c.ctrl.DrawBitmapContext(ctx, b, signName, opts...)
+}
+
+// Diff returns the dots that would flip if img were drawn to a sign, compared
+// with the last image sent to it. Until an image has been sent the sign is
+// assumed to be blank.
+func (c *Controller) Diff(img *image.Gray, signName string) (FrameDiff, error) {
+	return c.ctrl.Diff(img, signName)
 }
 
 // CreateImage creates a blank image for a specific sign
@@ -56,3 +325,32 @@ func (c *Controller) CreateImage(signName string) (*image.Gray, error) {
 	}
 	return s.CreateImage(), nil
 }
+
+// Tile places a sign on a virtual display, with the sign's top-left corner
+// at Offset on the display's canvas
+type Tile = controller.Tile
+
+// VirtualDisplay draws one large canvas across several signs, updating them
+// together
+type VirtualDisplay = controller.VirtualDisplay
+
+// AddVirtualDisplay registers a width x height display made of signs already
+// added with AddSign. Each sign's orientation is set with SetOrientation as
+// usual; if a sign no longer fits its tile, drawing to the display fails.
+func (c *Controller) AddVirtualDisplay(name string, width, height int, tiles ...Tile) (*VirtualDisplay, error) {
+	return c.ctrl.AddVirtualDisplay(name, width, height, tiles)
+}
+
+// VirtualDisplay returns a display registered with AddVirtualDisplay
+func (c *Controller) VirtualDisplay(name string) (*VirtualDisplay, error) {
+	return c.ctrl.VirtualDisplay(name)
+}
+
+// CreateBitmap creates a blank bitmap for a specific sign
+func (c *Controller) CreateBitmap(signName string) (*bitmap.Bitmap, error) {
+	s, err := c.ctrl.GetSign(signName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sign: %w", err)
+	}
+	return bitmap.New(s.ImageSize()), nil
+}
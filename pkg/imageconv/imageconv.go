@@ -0,0 +1,236 @@
+package imageconv
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+)
+
+// Scale is how an image is fitted to the sign
+type Scale int
+
+const (
+	// ScaleFit shrinks or grows the image to fit inside the sign, keeping its
+	// aspect ratio and leaving the rest of the sign blank
+	ScaleFit Scale = iota
+	// ScaleFill covers the whole sign, keeping the aspect ratio and cropping
+	// the edges that do not fit
+	ScaleFill
+	// ScaleStretch covers the whole sign, ignoring the aspect ratio
+	ScaleStretch
+)
+
+// Dither is how gray levels are reduced to on and off dots
+type Dither int
+
+const (
+	// DitherThreshold lights dots at least as bright as the threshold
+	DitherThreshold Dither = iota
+	// DitherFloydSteinberg diffuses the error to four neighbours, giving
+	// smooth gradients
+	DitherFloydSteinberg
+	// DitherAtkinson diffuses three quarters of the error, keeping more
+	// contrast on small signs
+	DitherAtkinson
+	// DitherBayer uses an 8x8 ordered pattern, which stays stable between
+	// animation frames
+	DitherBayer
+)
+
+// DefaultThreshold is the gray level at which dots start to be lit
+const DefaultThreshold = 128
+
+var (
+	ErrInvalidSize = errors.New("invalid sign size")
+)
+
+// Options controls how an image is converted
+type Options struct {
+	Scale  Scale
+	Dither Dither
+	// Threshold is used by DitherThreshold. Zero means DefaultThreshold.
+	Threshold uint8
+	// Invert lights the dark parts of the image, for dark logos on light
+	// backgrounds. Transparent parts of the image stay off.
+	Invert bool
+}
+
+// Convert scales src to width x height and reduces it to dots. The result
+// only contains black (off) and white (on) pixels. Transparent areas are off,
+// with or without Invert.
+func Convert(src image.Image, width, height int, opts Options) (*image.Gray, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("%w: %dx%d", ErrInvalidSize, width, height)
+	}
+	levels, cover := resample(src, width, height, opts.Scale)
+	if opts.Invert {
+		// Invert only as much of each dot as the image covers, so transparent
+		// parts and the margins left by ScaleFit stay off
+		for i, v := range levels {
+			levels[i] = cover[i] - v
+		}
+	}
+
+	dst := image.NewGray(image.Rect(0, 0, width, height))
+	switch opts.Dither {
+	case DitherFloydSteinberg:
+		diffuse(levels, width, height, floydSteinberg, 16)
+		threshold(dst, levels, DefaultThreshold)
+	case DitherAtkinson:
+		diffuse(levels, width, height, atkinson, 8)
+		threshold(dst, levels, DefaultThreshold)
+	case DitherBayer:
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				limit := (float64(bayer8[y%8][x%8]) + 0.5) * 255 / 64
+				if levels[y*width+x] > limit {
+					dst.Pix[y*dst.Stride+x] = 255
+				}
+			}
+		}
+	default:
+		t := opts.Threshold
+		if t == 0 {
+			t = DefaultThreshold
+		}
+		threshold(dst, levels, float64(t))
+	}
+	return dst, nil
+}
+
+// Decode reads a PNG, GIF or JPEG image and converts it
+func Decode(r io.Reader, width, height int, opts Options) (*image.Gray, error) {
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return Convert(src, width, height, opts)
+}
+
+// Load reads a PNG, GIF or JPEG file and converts it
+func Load(path string, width, height int, opts Options) (*image.Gray, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, err := Decode(file, width, height, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
+
+// resample scales src into a width x height grid of gray levels, averaging
+// the source pixels under each destination pixel. Levels are premultiplied by
+// cover, the opacity of the source under each pixel on the same 0-255 scale.
+func resample(src image.Image, width, height int, scale Scale) (levels, cover []float64) {
+	levels = make([]float64, width*height)
+	cover = make([]float64, width*height)
+	sb := src.Bounds()
+	if sb.Empty() {
+		return levels, cover
+	}
+
+	// target is the part of the sign the image covers and from is the part
+	// of the source that is shown
+	target := image.Rect(0, 0, width, height)
+	from := sb
+	switch scale {
+	case ScaleFit:
+		w, h := width, sb.Dy()*width/sb.Dx()
+		if h > height {
+			w, h = sb.Dx()*height/sb.Dy(), height
+		}
+		w, h = max(w, 1), max(h, 1)
+		target = image.Rect(0, 0, w, h).Add(image.Pt((width-w)/2, (height-h)/2))
+	case ScaleFill:
+		w, h := sb.Dx(), sb.Dx()*height/width
+		if h > sb.Dy() {
+			w, h = sb.Dy()*width/height, sb.Dy()
+		}
+		w, h = max(w, 1), max(h, 1)
+		from = image.Rect(0, 0, w, h).Add(sb.Min).Add(image.Pt((sb.Dx()-w)/2, (sb.Dy()-h)/2))
+	}
+
+	tw, th := target.Dx(), target.Dy()
+	for y := 0; y < th; y++ {
+		y0 := from.Min.Y + y*from.Dy()/th
+		y1 := max(from.Min.Y+(y+1)*from.Dy()/th, y0+1)
+		for x := 0; x < tw; x++ {
+			x0 := from.Min.X + x*from.Dx()/tw
+			x1 := max(from.Min.X+(x+1)*from.Dx()/tw, x0+1)
+			var sum, alpha float64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := src.At(sx, sy)
+					sum += float64(color.GrayModel.Convert(c).(color.Gray).Y)
+					_, _, _, a := c.RGBA()
+					alpha += float64(a >> 8)
+				}
+			}
+			n := float64((x1 - x0) * (y1 - y0))
+			i := (target.Min.Y+y)*width + target.Min.X + x
+			levels[i], cover[i] = sum/n, alpha/n
+		}
+	}
+	return levels, cover
+}
+
+func threshold(dst *image.Gray, levels []float64, t float64) {
+	for i, v := range levels {
+		if v >= t {
+			dst.Pix[i] = 255
+		}
+	}
+}
+
+// weight spreads part of a pixel's error to the pixel at dx, dy
+type weight struct {
+	dx, dy, w int
+}
+
+var (
+	floydSteinberg = []weight{{1, 0, 7}, {-1, 1, 3}, {0, 1, 5}, {1, 1, 1}}
+	atkinson       = []weight{{1, 0, 1}, {2, 0, 1}, {-1, 1, 1}, {0, 1, 1}, {1, 1, 1}, {0, 2, 1}}
+)
+
+// diffuse quantizes levels in place to 0 or 255, spreading each pixel's
+// error to its unvisited neighbours
+func diffuse(levels []float64, width, height int, weights []weight, divisor float64) {
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			old := levels[y*width+x]
+			quantized := 0.0
+			if old >= DefaultThreshold {
+				quantized = 255
+			}
+			levels[y*width+x] = quantized
+			e := old - quantized
+			for _, w := range weights {
+				nx, ny := x+w.dx, y+w.dy
+				if nx < 0 || nx >= width || ny >= height {
+					continue
+				}
+				levels[ny*width+nx] += e * float64(w.w) / divisor
+			}
+		}
+	}
+}
+
+var bayer8 = [8][8]int{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
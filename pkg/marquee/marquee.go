@@ -0,0 +1,252 @@
+package marquee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"time"
+
+	"github.com/harperreed/goflipdot/pkg/font"
+	"github.com/harperreed/goflipdot/pkg/goflipdot"
+)
+
+// DefaultMaxFPS is the refresh rate Hanover signs can sustain
+const DefaultMaxFPS = 2
+
+var (
+	ErrInvalidConfig = errors.New("invalid marquee config")
+)
+
+// Direction is the axis the text moves along
+type Direction int
+
+const (
+	// Horizontal scrolls text in from the right and out to the left
+	Horizontal Direction = iota
+	// Vertical scrolls text in from the bottom and out of the top
+	Vertical
+)
+
+// Mode controls what happens when the text reaches the end of its run
+type Mode int
+
+const (
+	// Loop scrolls the text through repeatedly
+	Loop Mode = iota
+	// PingPong scrolls back and forth, keeping as much text visible as possible
+	PingPong
+	// Once scrolls the text through a single time, ending on a blank sign
+	Once
+)
+
+// Config controls a Marquee
+type Config struct {
+	// Speed is how many columns (or rows, scrolling vertically) the text
+	// moves per second
+	Speed     float64
+	Direction Direction
+	Mode      Mode
+	// MaxFPS caps how often frames are produced; faster speeds move the text
+	// several dots per frame. Defaults to DefaultMaxFPS.
+	MaxFPS float64
+	// Gap is the blank space between repeats in Loop mode. Zero means the
+	// length of the sign, so one copy of the text is visible at a time.
+	Gap int
+	// Font defaults to the largest built-in font that fits the sign
+	Font *font.Font
+}
+
+// Marquee produces the frames of a scrolling strip
+type Marquee struct {
+	strip         *image.Gray
+	width, height int
+	cfg           Config
+	step          int
+	interval      time.Duration
+}
+
+// New renders text once and prepares to scroll it across a width x height sign
+func New(text string, width, height int, cfg Config) (*Marquee, error) {
+	f := cfg.Font
+	if f == nil {
+		f = font.ForHeight(height)
+	}
+	size := f.Measure(text)
+	var strip *image.Gray
+	if cfg.Direction == Vertical {
+		strip = image.NewGray(image.Rect(0, 0, width, size.Y))
+	} else {
+		strip = image.NewGray(image.Rect(0, 0, size.X, height))
+	}
+	// The strip fits the text along the scrolling axis, so overflow means
+	// the text is too tall (or, scrolling vertically, too wide) for the sign
+	if _, err := font.DrawText(strip, text, font.Options{Font: f, HAlign: font.AlignCenter, VAlign: font.AlignMiddle}); err != nil {
+		return nil, fmt.Errorf("failed to render text: %w", err)
+	}
+	return NewFromImage(strip, width, height, cfg)
+}
+
+// NewFromImage prepares to scroll a pre-rendered strip across a width x
+// height sign
+func NewFromImage(strip *image.Gray, width, height int, cfg Config) (*Marquee, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("%w: sign size %dx%d", ErrInvalidConfig, width, height)
+	}
+	if cfg.Speed <= 0 {
+		return nil, fmt.Errorf("%w: speed %v", ErrInvalidConfig, cfg.Speed)
+	}
+	if cfg.MaxFPS == 0 {
+		cfg.MaxFPS = DefaultMaxFPS
+	}
+	if cfg.MaxFPS < 0 || cfg.Gap < 0 {
+		return nil, ErrInvalidConfig
+	}
+
+	step := int(math.Ceil(cfg.Speed / cfg.MaxFPS))
+	return &Marquee{
+		strip:    strip,
+		width:    width,
+		height:   height,
+		cfg:      cfg,
+		step:     step,
+		interval: time.Duration(float64(step) / cfg.Speed * float64(time.Second)),
+	}, nil
+}
+
+// Interval returns the time between frames
+func (m *Marquee) Interval() time.Duration {
+	return m.interval
+}
+
+// Step returns how many dots the text moves per frame
+func (m *Marquee) Step() int {
+	return m.step
+}
+
+// Frame returns frame n of the animation. ok is false once a Once marquee
+// has finished.
+func (m *Marquee) Frame(n int) (img *image.Gray, ok bool) {
+	view, length := m.width, m.strip.Bounds().Dx()
+	if m.cfg.Direction == Vertical {
+		view, length = m.height, m.strip.Bounds().Dy()
+	}
+	pos := n * m.step
+
+	// sample maps a position along the view to a position in the strip, or
+	// -1 for blank space
+	var sample func(i int) int
+	switch m.cfg.Mode {
+	case Once:
+		if pos > length+view+m.step-1 {
+			return nil, false
+		}
+		if pos > length+view {
+			pos = length + view
+		}
+		sample = func(i int) int {
+			return inside(pos+i-view, length)
+		}
+	case PingPong:
+		travel := length - view
+		if travel < 0 {
+			travel = -travel
+		}
+		offset := bounce(pos, travel)
+		sample = func(i int) int {
+			if length > view {
+				return inside(offset+i, length)
+			}
+			return inside(i-offset, length)
+		}
+	default:
+		gap := m.cfg.Gap
+		if gap == 0 {
+			gap = view
+		}
+		period := length + gap
+		sample = func(i int) int {
+			c := (pos + i - view) % period
+			if c < 0 {
+				c += period
+			}
+			return inside(c, length)
+		}
+	}
+
+	img = image.NewGray(image.Rect(0, 0, m.width, m.height))
+	bounds := m.strip.Bounds()
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			sx, sy := x, y
+			if m.cfg.Direction == Vertical {
+				sy = sample(y)
+			} else {
+				sx = sample(x)
+			}
+			if sx < 0 || sy < 0 || sx >= bounds.Dx() || sy >= bounds.Dy() {
+				continue
+			}
+			img.SetGray(x, y, m.strip.GrayAt(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return img, true
+}
+
+// Run draws frames at the marquee's interval until a Once marquee finishes,
+// draw returns an error or ctx ends
+func (m *Marquee) Run(ctx context.Context, draw func(img *image.Gray) error) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for n := 0; ; n++ {
+		img, ok := m.Frame(n)
+		if !ok {
+			return nil
+		}
+		if err := draw(img); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Play scrolls text across the named sign until a Once marquee finishes or
+// ctx ends
+func Play(ctx context.Context, ctrl *goflipdot.Controller, signName, text string, cfg Config) error {
+	canvas, err := ctrl.CreateImage(signName)
+	if err != nil {
+		return err
+	}
+	bounds := canvas.Bounds()
+	m, err := New(text, bounds.Dx(), bounds.Dy(), cfg)
+	if err != nil {
+		return err
+	}
+	return m.Run(ctx, func(img *image.Gray) error {
+		return ctrl.DrawImageContext(ctx, img, signName)
+	})
+}
+
+func inside(i, length int) int {
+	if i < 0 || i >= length {
+		return -1
+	}
+	return i
+}
+
+// bounce folds pos into a triangle wave between 0 and travel
+func bounce(pos, travel int) int {
+	if travel == 0 {
+		return 0
+	}
+	pos %= 2 * travel
+	if pos > travel {
+		return 2*travel - pos
+	}
+	return pos
+}
@@ -0,0 +1,238 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the media type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the write
+// latency histogram. A full frame takes around half a second at 4800 baud.
+var DefaultLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// Collector counts controller events and serves them in Prometheus text
+// format. Pass it to goflipdot.WithMetrics and register it as an HTTP
+// handler, usually at /metrics.
+type Collector struct {
+	mu              sync.Mutex
+	framesSent      map[string]uint64
+	framesDropped   map[string]uint64
+	bytesWritten    uint64
+	readTimeouts    uint64
+	checksumErrors  uint64
+	latencyBuckets  []float64
+	latencyCounts   []uint64
+	latencySum      float64
+	latencyObserved uint64
+}
+
+// New creates a Collector using DefaultLatencyBuckets
+func New() *Collector {
+	return NewWithBuckets(DefaultLatencyBuckets)
+}
+
+// NewWithBuckets creates a Collector whose write latency histogram has the
+// given upper bounds in seconds
+func NewWithBuckets(buckets []float64) *Collector {
+	// The +Inf bucket is always written, so leave it out here
+	var b []float64
+	for _, le := range buckets {
+		if !math.IsInf(le, +1) {
+			b = append(b, le)
+		}
+	}
+	sort.Float64s(b)
+	return &Collector{
+		framesSent:     make(map[string]uint64),
+		framesDropped:  make(map[string]uint64),
+		latencyBuckets: b,
+		latencyCounts:  make([]uint64, len(b)),
+	}
+}
+
+// FrameSent counts a frame written to the named sign
+func (c *Collector) FrameSent(sign string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.framesSent[sign]++
+}
+
+// FrameDropped counts a frame for the named sign that was never sent
+func (c *Collector) FrameDropped(sign string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.framesDropped[sign]++
+}
+
+// Write counts bytes written to the transport and records how long the
+// write took
+func (c *Collector) Write(bytes int, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bytesWritten += uint64(bytes)
+	seconds := latency.Seconds()
+	for i, le := range c.latencyBuckets {
+		if seconds <= le {
+			c.latencyCounts[i]++
+		}
+	}
+	c.latencySum += seconds
+	c.latencyObserved++
+}
+
+// ReadTimeout counts a command whose response did not arrive in time
+func (c *Collector) ReadTimeout() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readTimeouts++
+}
+
+// ChecksumFailure counts a response with a bad checksum
+func (c *Collector) ChecksumFailure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checksumErrors++
+}
+
+// snapshot is a copy of a Collector's counters, taken so they can be
+// written out without holding the lock
+type snapshot struct {
+	framesSent      map[string]uint64
+	framesDropped   map[string]uint64
+	bytesWritten    uint64
+	readTimeouts    uint64
+	checksumErrors  uint64
+	latencyBuckets  []float64
+	latencyCounts   []uint64
+	latencySum      float64
+	latencyObserved uint64
+}
+
+func (c *Collector) snapshot() snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return snapshot{
+		framesSent:      copyCounts(c.framesSent),
+		framesDropped:   copyCounts(c.framesDropped),
+		bytesWritten:    c.bytesWritten,
+		readTimeouts:    c.readTimeouts,
+		checksumErrors:  c.checksumErrors,
+		latencyBuckets:  c.latencyBuckets,
+		latencyCounts:   append([]uint64(nil), c.latencyCounts...),
+		latencySum:      c.latencySum,
+		latencyObserved: c.latencyObserved,
+	}
+}
+
+func copyCounts(m map[string]uint64) map[string]uint64 {
+	out := make(map[string]uint64, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// WriteTo writes every metric to w in Prometheus text format. The counters
+// are copied first, so a slow writer does not hold up the controller.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	s := c.snapshot()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	perSign(cw, "flipdot_frames_sent_total", "Frames sent to each sign.", s.framesSent)
+	perSign(cw, "flipdot_frames_dropped_total", "Frames replaced by a newer frame before they were sent.", s.framesDropped)
+	counter(cw, "flipdot_bytes_written_total", "Bytes written to the transport.", s.bytesWritten)
+	counter(cw, "flipdot_read_timeouts_total", "Commands whose response did not arrive in time.", s.readTimeouts)
+	counter(cw, "flipdot_checksum_failures_total", "Responses with a bad checksum.", s.checksumErrors)
+
+	const latency = "flipdot_write_duration_seconds"
+	header(cw, latency, "Time taken to write a packet to the transport.", "histogram")
+	for i, le := range s.latencyBuckets {
+		fmt.Fprintf(cw, "%s_bucket{le=\"%s\"} %d\n", latency, formatFloat(le), s.latencyCounts[i])
+	}
+	fmt.Fprintf(cw, "%s_bucket{le=\"+Inf\"} %d\n", latency, s.latencyObserved)
+	fmt.Fprintf(cw, "%s_sum %s\n", latency, formatFloat(s.latencySum))
+	fmt.Fprintf(cw, "%s_count %d\n", latency, s.latencyObserved)
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP serves the metrics in Prometheus text format. They are rendered
+// in full before anything is sent, so a failure is reported as a 500 rather
+// than a truncated body.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if _, err := c.WriteTo(&buf); err != nil {
+		http.Error(w, fmt.Sprintf("failed to write metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	// An error here means the client has gone, so there is no one to tell
+	buf.WriteTo(w)
+}
+
+func header(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func counter(w io.Writer, name, help string, v uint64) {
+	header(w, name, help, "counter")
+	fmt.Fprintf(w, "%s %d\n", name, v)
+}
+
+// perSign writes a counter with one series per sign, sorted by name
+func perSign(w io.Writer, name, help string, values map[string]uint64) {
+	header(w, name, help, "counter")
+	signs := make([]string, 0, len(values))
+	for sign := range values {
+		signs = append(signs, sign)
+	}
+	sort.Strings(signs)
+	for _, sign := range signs {
+		fmt.Fprintf(w, "%s{sign=\"%s\"} %d\n", name, escapeLabel(sign), values[sign])
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countingWriter counts bytes written and keeps the first error
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
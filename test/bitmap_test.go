@@ -0,0 +1,141 @@
+package test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/harperreed/goflipdot/internal/packet"
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+	"github.com/harperreed/goflipdot/pkg/goflipdot"
+)
+
+func TestBitmap(t *testing.T) {
+	t.Run("SetGet", func(t *testing.T) {
+		b := bitmap.New(10, 3)
+		b.SetBit(9, 2, true)
+		b.Set(0, 0, color.White)
+		b.Set(1, 0, color.Gray{Y: 127})
+		if !b.Get(9, 2) || !b.Get(0, 0) || b.Get(1, 0) {
+			t.Errorf("Unexpected dots after Set: %08b", b.Pix)
+		}
+		if b.Get(10, 0) || b.Get(-1, 0) {
+			t.Error("Expected dots outside the bitmap to be off")
+		}
+		if b.At(0, 0) != bitmap.On || b.At(1, 0) != bitmap.Off {
+			t.Error("Unexpected colors from At")
+		}
+		if b.Count() != 2 {
+			t.Errorf("Unexpected count. Got %d, want 2", b.Count())
+		}
+	})
+
+	t.Run("FillInvert", func(t *testing.T) {
+		b := bitmap.New(10, 3)
+		b.Fill(true)
+		if b.Count() != 30 {
+			t.Errorf("Expected Fill to light 30 dots, got %d", b.Count())
+		}
+		b.SetBit(4, 1, false)
+		b.Invert()
+		if b.Count() != 1 || !b.Get(4, 1) {
+			t.Errorf("Expected Invert to leave only (4,1) lit, got %d dots", b.Count())
+		}
+	})
+
+	t.Run("Blit", func(t *testing.T) {
+		src := bitmap.New(3, 3)
+		src.Fill(true)
+		dst := bitmap.New(4, 4)
+		dst.Blit(src, src.Bounds(), image.Pt(2, 2))
+		if dst.Count() != 4 || !dst.Get(3, 3) || dst.Get(1, 1) {
+			t.Errorf("Expected the blit to be clipped to a 2x2 corner, got %d dots", dst.Count())
+		}
+
+		// Overlapping copies within one bitmap read the original dots
+		row := bitmap.New(4, 1)
+		row.SetBit(0, 0, true)
+		row.Blit(row, image.Rect(0, 0, 3, 1), image.Pt(1, 0))
+		if row.Count() != 2 || !row.Get(0, 0) || !row.Get(1, 0) {
+			t.Errorf("Unexpected overlapping blit result: %08b", row.Pix)
+		}
+	})
+
+	t.Run("DrawImage", func(t *testing.T) {
+		// Bitmap is a draw.Image, so the standard library can draw onto it
+		b := bitmap.New(8, 8)
+		draw.Draw(b, image.Rect(2, 2, 4, 4), image.White, image.Point{}, draw.Src)
+		if b.Count() != 4 {
+			t.Errorf("Expected draw.Draw to light 4 dots, got %d", b.Count())
+		}
+		round := bitmap.FromImage(b.Gray())
+		if !round.Equal(b) {
+			t.Error("Expected a bitmap to survive conversion to gray and back")
+		}
+	})
+
+	t.Run("Controller", func(t *testing.T) {
+		port := &fakeTransport{t: t}
+		ctrl, err := goflipdot.NewController(port)
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		defer ctrl.Close()
+		if err := ctrl.AddSign("dev", 1, 8, 8, false); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+
+		b, err := ctrl.CreateBitmap("dev")
+		if err != nil {
+			t.Fatalf("Failed to create bitmap: %v", err)
+		}
+		b.SetBit(3, 5, true)
+		if err := ctrl.DrawBitmap(b, "dev"); err != nil {
+			t.Fatalf("Failed to draw bitmap: %v", err)
+		}
+
+		img, _ := ctrl.CreateImage("dev")
+		img.SetGray(3, 5, color.Gray{Y: 255})
+		want, _ := packet.ImagePacket{Address: 1, Image: img}.GetBytes()
+		port.mu.Lock()
+		defer port.mu.Unlock()
+		if len(port.frames) != 1 || string(port.frames[0]) != string(want) {
+			t.Errorf("Expected the bitmap to be sent like the equivalent image. Got %q, want %q", port.frames, want)
+		}
+	})
+
+	t.Run("ControllerOriented", func(t *testing.T) {
+		port := &fakeTransport{t: t}
+		ctrl, err := goflipdot.NewController(port)
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		defer ctrl.Close()
+		if err := ctrl.AddSign("dev", 1, 16, 8, true); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		o := goflipdot.Orientation{Rotation: goflipdot.Rotate90, MirrorHorizontal: true}
+		if err := ctrl.SetOrientation("dev", o); err != nil {
+			t.Fatalf("Failed to set orientation: %v", err)
+		}
+
+		b, _ := ctrl.CreateBitmap("dev")
+		img, _ := ctrl.CreateImage("dev")
+		for _, p := range []image.Point{{0, 0}, {1, 3}, {6, 14}} {
+			b.SetBit(p.X, p.Y, true)
+			img.SetGray(p.X, p.Y, color.Gray{Y: 255})
+		}
+		if err := ctrl.DrawBitmap(b, "dev"); err != nil {
+			t.Fatalf("Failed to draw bitmap: %v", err)
+		}
+		if err := ctrl.DrawImage(img, "dev", goflipdot.Force()); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+		port.mu.Lock()
+		defer port.mu.Unlock()
+		if len(port.frames) != 2 || string(port.frames[0]) != string(port.frames[1]) {
+			t.Errorf("Expected the bitmap to be oriented like the equivalent image. Got %q", port.frames)
+		}
+	})
+}
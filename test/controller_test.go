@@ -2,9 +2,23 @@ package test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"image"
+	"image/color"
+	"io"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/harperreed/goflipdot/internal/packet"
+	"github.com/harperreed/goflipdot/pkg/emulator"
 	"github.com/harperreed/goflipdot/pkg/goflipdot"
 )
 
@@ -62,4 +76,713 @@ func TestController(t *testing.T) {
 			t.Error("Expected output for DrawImage, got empty buffer")
 		}
 	})
+
+	t.Run("DrawImageOriented", func(t *testing.T) {
+		if err := ctrl.AddSign("flipped", 2, 86, 7, true); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		buf.Reset()
+		img, err := ctrl.CreateImage("flipped")
+		if err != nil {
+			t.Fatalf("Failed to create image: %v", err)
+		}
+		img.Set(0, 0, color.White)
+		if err := ctrl.DrawImage(img, "flipped"); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+
+		pkt, err := packet.Decode(buf.Bytes(), 7)
+		if err != nil {
+			t.Fatalf("Failed to decode sent packet: %v", err)
+		}
+		sent := pkt.(packet.ImagePacket).Image
+		if sent.GrayAt(85, 6).Y != 255 || sent.GrayAt(0, 0).Y != 0 {
+			t.Error("Expected image to be rotated 180 degrees before sending")
+		}
+	})
+}
+
+// fakeTransport records every Write and fails the test if two writes overlap
+type fakeTransport struct {
+	t       *testing.T
+	mu      sync.Mutex
+	writing bool
+	frames  [][]byte
+}
+
+func (f *fakeTransport) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	if f.writing {
+		f.t.Error("Concurrent writes to transport")
+	}
+	f.writing = true
+	f.mu.Unlock()
+
+	time.Sleep(time.Microsecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writing = false
+	f.frames = append(f.frames, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (f *fakeTransport) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (f *fakeTransport) Close() error {
+	return nil
+}
+
+func TestControllerConcurrency(t *testing.T) {
+	port := &fakeTransport{t: t}
+	ctrl, err := goflipdot.NewController(port)
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+
+	const signs = 4
+	const framesPerSign = 25
+	var wg sync.WaitGroup
+	for i := 0; i < signs; i++ {
+		wg.Add(1)
+		go func(address int) {
+			defer wg.Done()
+			name := fmt.Sprintf("sign%d", address)
+			if err := ctrl.AddSign(name, address, 28, 19, address%2 == 0); err != nil {
+				t.Errorf("Failed to add sign: %v", err)
+				return
+			}
+			for n := 0; n < framesPerSign; n++ {
+				img, err := ctrl.CreateImage(name)
+				if err != nil {
+					t.Errorf("Failed to create image: %v", err)
+					return
+				}
+				img.Set(n, address, color.White)
+				if err := ctrl.DrawImage(img, name); err != nil {
+					t.Errorf("Failed to draw image: %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for n := 0; n < framesPerSign; n++ {
+			ctrl.SetOrientation("sign1", goflipdot.Orientation{MirrorHorizontal: n%2 == 0})
+		}
+	}()
+	wg.Wait()
+
+	port.mu.Lock()
+	defer port.mu.Unlock()
+	if len(port.frames) != signs*framesPerSign {
+		t.Fatalf("Unexpected frame count. Got %d, want %d", len(port.frames), signs*framesPerSign)
+	}
+	for _, frame := range port.frames {
+		if _, err := packet.Decode(frame, 19); err != nil {
+			t.Errorf("Corrupt frame on bus: %v", err)
+		}
+	}
+}
+
+// stuckTransport blocks every Write and Read until it is closed, like a
+// USB-serial adapter that has stopped draining its buffer
+type stuckTransport struct {
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newStuckTransport() *stuckTransport {
+	return &stuckTransport{closed: make(chan struct{})}
+}
+
+func (s *stuckTransport) Write(p []byte) (int, error) {
+	<-s.closed
+	return 0, io.ErrClosedPipe
+}
+
+func (s *stuckTransport) Read(p []byte) (int, error) {
+	<-s.closed
+	return 0, io.EOF
+}
+
+func (s *stuckTransport) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+func TestControllerContext(t *testing.T) {
+	t.Run("StuckWrite", func(t *testing.T) {
+		port := newStuckTransport()
+		defer port.Close()
+		ctrl, err := goflipdot.NewController(port)
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		if err := ctrl.AddSign("test", 1, 86, 7, false); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		img, _ := ctrl.CreateImage("test")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err = ctrl.DrawImageContext(ctx, img, "test")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("DrawImageContext blocked for %v", elapsed)
+		}
+
+		// The bus is still stuck, so queued requests must also honor ctx
+		ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := ctrl.StartTestSignsContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded for queued command, got %v", err)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		ctrl, err := goflipdot.NewController(buf)
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := ctrl.StopTestSignsContext(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context canceled, got %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Error("Nothing should be written for a cancelled context")
+		}
+	})
+
+	t.Run("ResponseWait", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer server.Close()
+		go io.Copy(io.Discard, server)
+
+		ctrl, err := goflipdot.NewController(client, goflipdot.WithResponseTimeout(time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := ctrl.StartTestSignsContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded while awaiting response, got %v", err)
+		}
+	})
+	t.Run("WriteDeadline", func(t *testing.T) {
+		first := image.NewGray(image.Rect(0, 0, 86, 7))
+		first.Pix[0] = 0xFF
+		frame, _ := packet.ImagePacket{Address: 1, Image: first}.GetBytes()
+
+		// Cut the frame in the payload, just after the end byte and between
+		// the checksum digits
+		for _, cut := range []int{5, len(frame) - 2, len(frame) - 1} {
+			t.Run(fmt.Sprint(cut), func(t *testing.T) {
+				client, server := net.Pipe()
+				defer server.Close()
+
+				var reopened bool
+				states := make(chan goflipdot.ConnectionState, 4)
+				ctrl, err := goflipdot.NewController(client,
+					goflipdot.WithReopen(func() (io.ReadWriteCloser, error) {
+						reopened = true
+						return nil, errors.New("no such device")
+					}),
+					goflipdot.WithStateHandler(func(state goflipdot.ConnectionState, err error) {
+						states <- state
+					}),
+				)
+				if err != nil {
+					t.Fatalf("Failed to create controller: %v", err)
+				}
+				defer ctrl.Close()
+				if err := ctrl.AddSign("test", 1, 86, 7, false); err != nil {
+					t.Fatalf("Failed to add sign: %v", err)
+				}
+
+				// The sign reads the start of the frame and then stalls
+				emu := emulator.New(7)
+				emu.AddSign(1, 86, 7)
+				var rejected []error
+				var rejectedMu sync.Mutex
+				emu.OnError(func(err error) {
+					rejectedMu.Lock()
+					defer rejectedMu.Unlock()
+					rejected = append(rejected, err)
+				})
+				headRead := make(chan struct{})
+				go func() {
+					defer close(headRead)
+					head := make([]byte, cut)
+					n, _ := io.ReadFull(server, head)
+					emu.Write(head[:n])
+				}()
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				defer cancel()
+				if err := ctrl.DrawImageContext(ctx, first, "test"); !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("Expected deadline exceeded, got %v", err)
+				}
+				select {
+				case <-headRead:
+				case <-time.After(time.Second):
+					t.Fatal("The frame was never written")
+				}
+				// DrawImageContext returns as soon as ctx ends; give the
+				// transport's own write deadline time to fire too
+				time.Sleep(50 * time.Millisecond)
+
+				select {
+				case state := <-states:
+					t.Fatalf("A caller's deadline changed the connection state to %v", state)
+				default:
+				}
+				if ctrl.State() != goflipdot.StateConnected {
+					t.Errorf("Unexpected state: %v", ctrl.State())
+				}
+
+				// The frame cut short is completed with a bad checksum so it
+				// cannot corrupt the next one
+				go emu.Serve(server)
+				second, _ := ctrl.CreateImage("test")
+				second.Pix[1] = 0xFF
+				if err := ctrl.DrawImage(second, "test"); err != nil {
+					t.Fatalf("Failed to draw after deadline: %v", err)
+				}
+				// Rejections are reported after the frames are applied, so
+				// wait for both
+				deadline := time.Now().Add(time.Second)
+				for {
+					rejectedMu.Lock()
+					n := len(rejected)
+					rejectedMu.Unlock()
+					if img, ok := emu.Image(1); ok && bytes.Equal(img.Pix, second.Pix) && n > 0 {
+						break
+					}
+					if time.Now().After(deadline) {
+						t.Fatal("Sign did not show the frame sent after the deadline")
+					}
+					time.Sleep(5 * time.Millisecond)
+				}
+				rejectedMu.Lock()
+				if len(rejected) != 1 || !errors.Is(rejected[0], packet.ErrChecksum) {
+					t.Errorf("Expected the cut frame to fail its checksum, got %v", rejected)
+				}
+				rejectedMu.Unlock()
+				if reopened {
+					t.Error("Transport was reopened after a caller's deadline")
+				}
+			})
+		}
+	})
+}
+
+// unpluggableTransport accepts writes until unplugged, then fails halfway
+// through the next frame like a USB adapter pulled mid-transfer
+type unpluggableTransport struct {
+	mu        sync.Mutex
+	unplugged bool
+	closed    bool
+	written   []byte
+}
+
+func (u *unpluggableTransport) Write(p []byte) (int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if u.unplugged {
+		half := len(p) / 2
+		u.written = append(u.written, p[:half]...)
+		return half, errors.New("device not configured")
+	}
+	u.written = append(u.written, p...)
+	return len(p), nil
+}
+
+func (u *unpluggableTransport) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (u *unpluggableTransport) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.closed = true
+	return nil
+}
+
+func TestControllerReconnect(t *testing.T) {
+	first := &unpluggableTransport{}
+	second := &unpluggableTransport{}
+
+	var opens int
+	var openMu sync.Mutex
+	reopen := func() (io.ReadWriteCloser, error) {
+		openMu.Lock()
+		defer openMu.Unlock()
+		opens++
+		if opens < 3 {
+			return nil, errors.New("no such device")
+		}
+		return second, nil
+	}
+	states := make(chan goflipdot.ConnectionState, 4)
+	ctrl, err := goflipdot.NewController(first,
+		goflipdot.WithReopen(reopen),
+		goflipdot.WithBackoff(time.Millisecond, 4*time.Millisecond),
+		goflipdot.WithStateHandler(func(state goflipdot.ConnectionState, err error) {
+			states <- state
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	if err := ctrl.AddSign("test", 1, 86, 7, false); err != nil {
+		t.Fatalf("Failed to add sign: %v", err)
+	}
+	img, _ := ctrl.CreateImage("test")
+
+	if err := ctrl.DrawImage(img, "test"); err != nil {
+		t.Fatalf("Failed to draw image: %v", err)
+	}
+
+	first.mu.Lock()
+	first.unplugged = true
+	first.mu.Unlock()
+	if err := ctrl.DrawImage(img, "test", goflipdot.Force()); err == nil {
+		t.Fatal("Expected write to fail after unplugging")
+	}
+
+	waitState := func(want goflipdot.ConnectionState) {
+		t.Helper()
+		select {
+		case got := <-states:
+			if got != want {
+				t.Fatalf("Unexpected state. Got %v, want %v", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for state %v", want)
+		}
+	}
+	waitState(goflipdot.StateDisconnected)
+	first.mu.Lock()
+	if !first.closed {
+		t.Error("Expected failed transport to be closed")
+	}
+	first.mu.Unlock()
+	waitState(goflipdot.StateConnected)
+	if ctrl.State() != goflipdot.StateConnected {
+		t.Errorf("Unexpected state after reconnect: %v", ctrl.State())
+	}
+
+	if err := ctrl.DrawImage(img, "test"); err != nil {
+		t.Fatalf("Failed to draw image after reconnect: %v", err)
+	}
+	second.mu.Lock()
+	pkt, err := packet.Decode(second.written, 7)
+	second.mu.Unlock()
+	if err != nil {
+		t.Fatalf("Expected a complete frame on the new transport: %v", err)
+	}
+	if pkt.(packet.ImagePacket).Address != 1 {
+		t.Error("Unexpected address on new transport")
+	}
+
+	if err := ctrl.Close(); err != nil {
+		t.Errorf("Failed to close controller: %v", err)
+	}
+	second.mu.Lock()
+	if !second.closed {
+		t.Error("Expected Close to close the transport")
+	}
+	second.mu.Unlock()
+	if err := ctrl.DrawImage(img, "test"); !errors.Is(err, goflipdot.ErrClosed) {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestControllerFrameRate(t *testing.T) {
+	port := &fakeTransport{t: t}
+	ctrl, err := goflipdot.NewController(port, goflipdot.WithMaxFPS(10))
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	defer ctrl.Close()
+	if err := ctrl.AddSign("dev", 1, 8, 8, false); err != nil {
+		t.Fatalf("Failed to add sign: %v", err)
+	}
+
+	frame := func(x int) *image.Gray {
+		img, _ := ctrl.CreateImage("dev")
+		img.SetGray(x, 0, color.Gray{Y: 255})
+		return img
+	}
+
+	t.Run("Coalesce", func(t *testing.T) {
+		start := time.Now()
+		if err := ctrl.DrawImage(frame(0), "dev"); err != nil {
+			t.Fatalf("Failed to draw first frame: %v", err)
+		}
+
+		// Frames drawn within the 100ms slot replace each other
+		var wg sync.WaitGroup
+		for x := 1; x <= 3; x++ {
+			wg.Add(1)
+			go func(x int) {
+				defer wg.Done()
+				if err := ctrl.DrawImage(frame(x), "dev"); err != nil {
+					t.Errorf("Failed to draw frame %d: %v", x, err)
+				}
+			}(x)
+			time.Sleep(10 * time.Millisecond)
+		}
+		wg.Wait()
+
+		if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+			t.Errorf("Second frame was sent after %v, before the 100ms slot", elapsed)
+		}
+		port.mu.Lock()
+		frames := port.frames
+		port.mu.Unlock()
+		if len(frames) != 2 {
+			t.Fatalf("Expected 2 frames on the bus, got %d", len(frames))
+		}
+		want, _ := packet.ImagePacket{Address: 1, Image: frame(3)}.GetBytes()
+		if !bytes.Equal(frames[1], want) {
+			t.Errorf("Expected the latest frame to be sent. Got %q, want %q", frames[1], want)
+		}
+		if dropped, _ := ctrl.DroppedFrames("dev"); dropped != 2 {
+			t.Errorf("Unexpected dropped frame count. Got %d, want 2", dropped)
+		}
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		if err := ctrl.SetMaxFPS("dev", 0); err != nil {
+			t.Fatalf("Failed to remove frame rate limit: %v", err)
+		}
+		port.mu.Lock()
+		before := len(port.frames)
+		port.mu.Unlock()
+		for x := 0; x < 3; x++ {
+			if err := ctrl.DrawImage(frame(x), "dev"); err != nil {
+				t.Fatalf("Failed to draw frame: %v", err)
+			}
+		}
+		port.mu.Lock()
+		defer port.mu.Unlock()
+		if got := len(port.frames) - before; got != 3 {
+			t.Errorf("Expected every frame to be sent, got %d of 3", got)
+		}
+	})
+}
+
+func TestControllerDirtyFrames(t *testing.T) {
+	port := &fakeTransport{t: t}
+	ctrl, err := goflipdot.NewController(port)
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	defer ctrl.Close()
+	if err := ctrl.AddSign("dev", 1, 8, 8, false); err != nil {
+		t.Fatalf("Failed to add sign: %v", err)
+	}
+	sent := func() int {
+		port.mu.Lock()
+		defer port.mu.Unlock()
+		return len(port.frames)
+	}
+
+	img, _ := ctrl.CreateImage("dev")
+	img.SetGray(1, 2, color.Gray{Y: 255})
+	img.SetGray(3, 4, color.Gray{Y: 255})
+
+	t.Run("Diff", func(t *testing.T) {
+		d, err := ctrl.Diff(img, "dev")
+		if err != nil {
+			t.Fatalf("Failed to diff: %v", err)
+		}
+		if d.Changed() != 2 || len(d.Cleared) != 0 {
+			t.Errorf("Expected 2 dots set against a blank sign, got %+v", d)
+		}
+
+		if err := ctrl.DrawImage(img, "dev"); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+		next, _ := ctrl.CreateImage("dev")
+		next.SetGray(1, 2, color.Gray{Y: 255})
+		next.SetGray(5, 6, color.Gray{Y: 255})
+		d, _ = ctrl.Diff(next, "dev")
+		if len(d.Set) != 1 || d.Set[0] != image.Pt(5, 6) {
+			t.Errorf("Unexpected set dots: %v", d.Set)
+		}
+		if len(d.Cleared) != 1 || d.Cleared[0] != image.Pt(3, 4) {
+			t.Errorf("Unexpected cleared dots: %v", d.Cleared)
+		}
+	})
+
+	t.Run("SkipIdentical", func(t *testing.T) {
+		before := sent()
+		// A separate image with the same dots counts as identical
+		dup, _ := ctrl.CreateImage("dev")
+		copy(dup.Pix, img.Pix)
+		if err := ctrl.DrawImage(dup, "dev"); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+		if got := sent() - before; got != 0 {
+			t.Errorf("Expected identical frame to be skipped, %d sent", got)
+		}
+		if err := ctrl.DrawImage(dup, "dev", goflipdot.Force()); err != nil {
+			t.Fatalf("Failed to force image: %v", err)
+		}
+		if got := sent() - before; got != 1 {
+			t.Errorf("Expected forced frame to be sent, %d sent", got)
+		}
+	})
+
+	t.Run("OrientationChange", func(t *testing.T) {
+		before := sent()
+		if err := ctrl.SetOrientation("dev", goflipdot.Orientation{MirrorHorizontal: true}); err != nil {
+			t.Fatalf("Failed to set orientation: %v", err)
+		}
+		if err := ctrl.DrawImage(img, "dev"); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+		if got := sent() - before; got != 1 {
+			t.Errorf("Expected frame to be resent after reorienting, %d sent", got)
+		}
+	})
+	t.Run("TestSigns", func(t *testing.T) {
+		if err := ctrl.StartTestSigns(); err != nil {
+			t.Fatalf("Failed to start test signs: %v", err)
+		}
+		if err := ctrl.StopTestSigns(); err != nil {
+			t.Fatalf("Failed to stop test signs: %v", err)
+		}
+		before := sent()
+		if err := ctrl.DrawImage(img, "dev"); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+		if got := sent() - before; got != 1 {
+			t.Errorf("Expected frame to be resent after the test pattern, %d sent", got)
+		}
+	})
+}
+
+func TestControllerSignModel(t *testing.T) {
+	ctrl, err := goflipdot.NewController(new(bytes.Buffer))
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	defer ctrl.Close()
+
+	if err := ctrl.AddSignModel("dev", 1, "7000-28x19"); err != nil {
+		t.Fatalf("Failed to add sign model: %v", err)
+	}
+	img, _ := ctrl.CreateImage("dev")
+	if img.Bounds() != image.Rect(0, 0, 28, 19) {
+		t.Errorf("Unexpected image size %v for 7000-28x19", img.Bounds())
+	}
+	if err := ctrl.AddSignModel("other", 2, "7000-0x0"); !errors.Is(err, goflipdot.ErrUnknownModel) {
+		t.Errorf("Expected ErrUnknownModel, got %v", err)
+	}
+
+	// Models registered without a refresh rate are still rate limited
+	if err := goflipdot.RegisterModel(goflipdot.Model{Name: "custom-30x7", Columns: 30, Rows: 7}); err != nil {
+		t.Fatalf("Failed to register model: %v", err)
+	}
+	if err := ctrl.AddSignModel("custom", 3, "custom-30x7"); err != nil {
+		t.Fatalf("Failed to add sign model: %v", err)
+	}
+	img, _ = ctrl.CreateImage("custom")
+	if err := ctrl.DrawImage(img, "custom"); err != nil {
+		t.Fatalf("Failed to draw image: %v", err)
+	}
+	img.Pix[0] = 0xFF
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := ctrl.DrawImageContext(ctx, img, "custom"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the second frame to wait for the default refresh rate, got %v", err)
+	}
+}
+
+func TestControllerLogging(t *testing.T) {
+	t.Run("DebugPackets", func(t *testing.T) {
+		port := new(bytes.Buffer)
+		logs := new(bytes.Buffer)
+		logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		ctrl, err := goflipdot.NewController(port, goflipdot.WithLogger(logger))
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		defer ctrl.Close()
+		if err := ctrl.AddSign("test", 3, 86, 7, false); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		img, err := ctrl.CreateImage("test")
+		if err != nil {
+			t.Fatalf("Failed to create image: %v", err)
+		}
+		if err := ctrl.DrawImage(img, "test"); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+		frame := port.Bytes()
+
+		var record struct {
+			Level    string
+			Msg      string
+			Address  int
+			Command  string
+			Length   int
+			Checksum string
+		}
+		line, _, _ := bytes.Cut(logs.Bytes(), []byte("\n"))
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("Failed to decode log record %q: %v", line, err)
+		}
+		if record.Level != "DEBUG" || record.Msg != "Sending packet" {
+			t.Errorf("Unexpected record %s %q", record.Level, record.Msg)
+		}
+		if record.Address != 3 || record.Command != "1" || record.Length != len(frame) {
+			t.Errorf("Unexpected packet fields: %+v", record)
+		}
+		if want := string(frame[len(frame)-2:]); record.Checksum != want {
+			t.Errorf("Unexpected checksum. Got %s, want %s", record.Checksum, want)
+		}
+	})
+
+	t.Run("SilentByDefault", func(t *testing.T) {
+		logs := new(bytes.Buffer)
+		log.SetOutput(logs)
+		defer log.SetOutput(os.Stderr)
+
+		ctrl, err := goflipdot.NewController(new(bytes.Buffer))
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		if err := ctrl.AddSign("test", 1, 86, 7, false); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		img, err := ctrl.CreateImage("test")
+		if err != nil {
+			t.Fatalf("Failed to create image: %v", err)
+		}
+		if err := ctrl.DrawImage(img, "test"); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+		if err := ctrl.StartTestSigns(); err != nil {
+			t.Fatalf("Failed to start test: %v", err)
+		}
+		ctrl.Close()
+		if logs.Len() > 0 {
+			t.Errorf("Expected no log output, got %q", logs.String())
+		}
+	})
 }
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/harperreed/goflipdot/internal/packet"
+	"github.com/harperreed/goflipdot/pkg/emulator"
+	"github.com/harperreed/goflipdot/pkg/goflipdot"
+)
+
+func TestEmulator(t *testing.T) {
+	t.Run("TestMode", func(t *testing.T) {
+		emu := emulator.New(16)
+		start, _ := packet.TestSignsStartPacket{}.GetBytes()
+		stop, _ := packet.TestSignsStopPacket{}.GetBytes()
+
+		emu.Write(start)
+		if !emu.TestMode() {
+			t.Error("Expected test mode after start packet")
+		}
+		emu.Write(stop)
+		if emu.TestMode() {
+			t.Error("Expected test mode to end after stop packet")
+		}
+	})
+
+	t.Run("ImagePacket", func(t *testing.T) {
+		emu := emulator.New(16)
+		img := image.NewGray(image.Rect(0, 0, 96, 16))
+		img.Set(0, 0, color.White)
+		img.Set(95, 15, color.White)
+		img.Set(40, 9, color.White)
+
+		p := packet.ImagePacket{Address: 3, Image: img}
+		b, err := p.GetBytes()
+		if err != nil {
+			t.Fatalf("Failed to get bytes: %v", err)
+		}
+		// Deliver the frame in two chunks with noise in front
+		emu.Write(append([]byte{0xFF, 'x'}, b[:10]...))
+		emu.Write(b[10:])
+
+		got, ok := emu.Image(3)
+		if !ok {
+			t.Fatal("Expected sign at address 3")
+		}
+		if got.Bounds() != img.Bounds() {
+			t.Fatalf("Unexpected emulated size. Got %v, want %v", got.Bounds(), img.Bounds())
+		}
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 96; x++ {
+				if got.GrayAt(x, y) != img.GrayAt(x, y) {
+					t.Fatalf("Dot mismatch at (%d, %d)", x, y)
+				}
+			}
+		}
+	})
+
+	t.Run("BadChecksum", func(t *testing.T) {
+		emu := emulator.New(16)
+		var gotErr error
+		emu.OnError(func(err error) { gotErr = err })
+
+		b, _ := packet.TestSignsStartPacket{}.GetBytes()
+		b[len(b)-1] = '0'
+		emu.Write(b)
+		if !errors.Is(gotErr, emulator.ErrChecksum) {
+			t.Errorf("Expected checksum error, got %v", gotErr)
+		}
+		if emu.TestMode() {
+			t.Error("Frame with bad checksum should be ignored")
+		}
+	})
+
+	t.Run("PTY", func(t *testing.T) {
+		emu := emulator.New(7)
+		if err := emu.AddSign(1, 86, 7); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		updated := make(chan int, 1)
+		emu.OnUpdate(func(address int) { updated <- address })
+
+		pty, err := emu.ServePTY()
+		if err != nil {
+			t.Skipf("pty not available: %v", err)
+		}
+		defer pty.Close()
+
+		ctrl, err := goflipdot.NewSerialController(pty.Name, goflipdot.WithResponseTimeout(0))
+		if err != nil {
+			t.Fatalf("Failed to open pty: %v", err)
+		}
+		if err := ctrl.AddSign("dev", 1, 86, 7, false); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		img, _ := ctrl.CreateImage("dev")
+		img.Set(10, 3, color.White)
+		if err := ctrl.DrawImage(img, "dev"); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+
+		select {
+		case address := <-updated:
+			if address != 1 {
+				t.Errorf("Unexpected address. Got %d, want 1", address)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Emulator did not receive the image")
+		}
+		got, _ := emu.Image(1)
+		if got.GrayAt(10, 3).Y != 255 {
+			t.Error("Expected dot (10, 3) to be set")
+		}
+	})
+}
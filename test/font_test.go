@@ -0,0 +1,189 @@
+package test
+
+import (
+	"errors"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/harperreed/goflipdot/pkg/font"
+)
+
+func TestFont(t *testing.T) {
+	t.Run("ForHeight", func(t *testing.T) {
+		cases := map[int]*font.Font{
+			7:  font.Font5x7,
+			16: font.Font9x16,
+			19: font.Font9x19,
+			5:  font.Font5x7,
+		}
+		for rows, want := range cases {
+			if got := font.ForHeight(rows); got != want {
+				t.Errorf("Unexpected font for %d rows. Got %s, want %s", rows, got.Name, want.Name)
+			}
+		}
+	})
+
+	t.Run("Measure", func(t *testing.T) {
+		// H is 5 wide, i is 1 wide, with 1 column of tracking
+		if got := font.Font5x7.Measure("Hi"); got != image.Pt(7, 7) {
+			t.Errorf("Unexpected size for Hi. Got %v, want (7,7)", got)
+		}
+		if got := font.Font5x7.Measure("Hi\nHi"); got != image.Pt(7, 15) {
+			t.Errorf("Unexpected size for two lines. Got %v, want (7,15)", got)
+		}
+		if got := font.Font9x16.Measure("Hi"); got != image.Pt(11, 16) {
+			t.Errorf("Unexpected size in the 16-row font. Got %v, want (11,16)", got)
+		}
+		// T and o are kerned one column closer
+		if got, unkerned := font.Font5x7.Measure("To").X, font.Font5x7.Measure("TH").X; got != unkerned-1 {
+			t.Errorf("Expected To to be kerned. Got width %d, want %d", got, unkerned-1)
+		}
+	})
+
+	t.Run("Alignment", func(t *testing.T) {
+		img := image.NewGray(image.Rect(0, 0, 96, 16))
+		cases := []struct {
+			h    font.HAlign
+			v    font.VAlign
+			want image.Rectangle
+		}{
+			{font.AlignLeft, font.AlignTop, image.Rect(0, 0, 7, 7)},
+			{font.AlignCenter, font.AlignMiddle, image.Rect(44, 4, 51, 11)},
+			{font.AlignRight, font.AlignBottom, image.Rect(89, 9, 96, 16)},
+		}
+		for _, tc := range cases {
+			got, err := font.DrawText(img, "Hi", font.Options{Font: font.Font5x7, HAlign: tc.h, VAlign: tc.v})
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Unexpected text bounds. Got %v, want %v", got, tc.want)
+			}
+			if img.GrayAt(got.Min.X, got.Min.Y).Y != 255 {
+				t.Errorf("Expected top-left dot of H at %v to be set", got.Min)
+			}
+		}
+	})
+
+	t.Run("Overflow", func(t *testing.T) {
+		img := image.NewGray(image.Rect(0, 0, 20, 7))
+		_, err := font.DrawText(img, "Too long for the sign", font.Options{})
+		if !errors.Is(err, font.ErrOverflow) {
+			t.Errorf("Expected ErrOverflow, got %v", err)
+		}
+		if img.GrayAt(0, 0).Y != 255 {
+			t.Error("Expected visible part of the text to be drawn")
+		}
+	})
+
+	t.Run("BuiltinCoverage", func(t *testing.T) {
+		for _, f := range []*font.Font{font.Font5x7, font.Font9x16, font.Font9x19} {
+			for r := rune(' '); r <= '~'; r++ {
+				if g, ok := f.Glyph(r); !ok || g.Height != f.Height {
+					t.Errorf("Expected a %d-row glyph for %q in %s", f.Height, r, f.Name)
+				}
+			}
+			if _, ok := f.Glyph('°'); !ok {
+				t.Errorf("Expected a glyph for ° in %s", f.Name)
+			}
+		}
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		if _, ok := font.Font5x7.Glyph('€'); ok {
+			t.Error("Did not expect a glyph for €")
+		}
+		g, _ := font.Font5x7.Glyph('€')
+		if g == nil || g.Width != 5 {
+			t.Error("Expected fallback glyph for uncovered rune")
+		}
+	})
+}
+
+func TestFontFiles(t *testing.T) {
+	t.Run("BDF", func(t *testing.T) {
+		f, err := font.LoadFile("testdata/fonts/tiny.bdf")
+		if err != nil {
+			t.Fatalf("Failed to load BDF: %v", err)
+		}
+		if f.Height != 6 {
+			t.Errorf("Unexpected height. Got %d, want 6", f.Height)
+		}
+		a, ok := f.Glyph('A')
+		if !ok || a.Width != 4 {
+			t.Fatalf("Expected 4 column glyph for A")
+		}
+		if !a.At(1, 0) || !a.At(0, 4) || a.At(0, 5) {
+			t.Error("Unexpected dots in A")
+		}
+		if _, ok := f.Glyph('é'); !ok {
+			t.Error("Expected glyph for é")
+		}
+		// j has a descender that reaches the bottom row
+		j, _ := f.Glyph('j')
+		if !j.At(0, 5) || !j.At(1, 1) || j.At(1, 0) {
+			t.Error("Expected j to be placed on the baseline with its descender below")
+		}
+		fallback, ok := f.Glyph('Z')
+		question, _ := f.Glyph('?')
+		if ok || fallback != question {
+			t.Error("Expected DEFAULT_CHAR to be used for uncovered runes")
+		}
+
+		img := image.NewGray(image.Rect(0, 0, 20, 6))
+		got, err := font.DrawText(img, "AB", font.Options{Font: f})
+		if err != nil || got != image.Rect(0, 0, 8, 6) {
+			t.Errorf("Unexpected text bounds %v, %v", got, err)
+		}
+	})
+
+	t.Run("PSF1", func(t *testing.T) {
+		f, err := font.LoadFile("testdata/fonts/tiny.psf")
+		if err != nil {
+			t.Fatalf("Failed to load PSF1: %v", err)
+		}
+		if f.Height != 8 {
+			t.Errorf("Unexpected height. Got %d, want 8", f.Height)
+		}
+		a, ok := f.Glyph('A')
+		alpha, alphaOK := f.Glyph('Α')
+		if !ok || !alphaOK || a != alpha {
+			t.Error("Expected A and Greek Alpha to share a glyph")
+		}
+		if a.Width != 8 || !a.At(3, 0) || !a.At(1, 3) {
+			t.Error("Unexpected dots in A")
+		}
+		if g, ok := f.Glyph('€'); ok || g == nil {
+			t.Error("Expected fallback glyph for €")
+		}
+	})
+
+	t.Run("PSF2", func(t *testing.T) {
+		f, err := font.LoadFile("testdata/fonts/tiny.psfu")
+		if err != nil {
+			t.Fatalf("Failed to load PSF2: %v", err)
+		}
+		if f.Height != 10 {
+			t.Errorf("Unexpected height. Got %d, want 10", f.Height)
+		}
+		euro, ok := f.Glyph('€')
+		if !ok || euro.Width != 6 || !euro.At(1, 1) {
+			t.Error("Expected 6 column glyph for €")
+		}
+		fallback, ok := f.Glyph('Q')
+		replacement, _ := f.Glyph('�')
+		if ok || fallback != replacement {
+			t.Error("Expected replacement character to be the fallback")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := font.LoadPSF(strings.NewReader("not a font")); !errors.Is(err, font.ErrInvalidFont) {
+			t.Errorf("Expected ErrInvalidFont, got %v", err)
+		}
+		if _, err := font.LoadBDF(strings.NewReader("STARTFONT 2.1\nBBX 1 2\n")); !errors.Is(err, font.ErrInvalidFont) {
+			t.Errorf("Expected ErrInvalidFont, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package test
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+	"github.com/harperreed/goflipdot/pkg/gfx"
+)
+
+// render draws b as rows of # and .
+func render(b *bitmap.Bitmap) string {
+	var sb strings.Builder
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			if b.Get(x, y) {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func expectDots(t *testing.T, b *bitmap.Bitmap, want string) {
+	t.Helper()
+	want = strings.TrimPrefix(want, "\n")
+	if got := render(b); got != want {
+		t.Errorf("Unexpected dots.\nGot:\n%sWant:\n%s", got, want)
+	}
+}
+
+func TestGfx(t *testing.T) {
+	t.Run("Line", func(t *testing.T) {
+		b := bitmap.New(7, 3)
+		gfx.New(b).Line(0, 0, 6, 2)
+		expectDots(t, b, `
+##.....
+..###..
+.....##
+`)
+	})
+
+	t.Run("Rect", func(t *testing.T) {
+		b := bitmap.New(5, 4)
+		p := gfx.New(b)
+		p.Rect(image.Rect(0, 0, 5, 4))
+		p.FillRect(image.Rect(2, 1, 3, 3))
+		expectDots(t, b, `
+#####
+#.#.#
+#.#.#
+#####
+`)
+	})
+
+	t.Run("Circle", func(t *testing.T) {
+		b := bitmap.New(7, 7)
+		gfx.New(b).Circle(3, 3, 3)
+		expectDots(t, b, `
+..###..
+.#...#.
+#.....#
+#.....#
+#.....#
+.#...#.
+..###..
+`)
+	})
+
+	t.Run("Ellipse", func(t *testing.T) {
+		b := bitmap.New(11, 5)
+		gfx.New(b).Ellipse(5, 2, 5, 2)
+		expectDots(t, b, `
+..#######..
+.#.......#.
+#.........#
+.#.......#.
+..#######..
+`)
+	})
+
+	t.Run("Polygon", func(t *testing.T) {
+		b := bitmap.New(5, 3)
+		gfx.New(b).Polygon(image.Pt(0, 2), image.Pt(2, 0), image.Pt(4, 2))
+		expectDots(t, b, `
+..#..
+.#.#.
+#####
+`)
+	})
+
+	t.Run("FloodFill", func(t *testing.T) {
+		b := bitmap.New(5, 5)
+		p := gfx.New(b)
+		p.Rect(image.Rect(0, 0, 5, 5))
+		p.FloodFill(2, 2)
+		if b.Count() != 25 {
+			t.Errorf("Expected the inside to be filled, got %d dots", b.Count())
+		}
+		p.Mode = gfx.ModeClear
+		p.FloodFill(0, 0)
+		if b.Count() != 0 {
+			t.Errorf("Expected clearing flood fill to empty the canvas, got %d dots", b.Count())
+		}
+	})
+
+	t.Run("XOR", func(t *testing.T) {
+		b := bitmap.New(7, 7)
+		p := gfx.New(b)
+		p.Mode = gfx.ModeXOR
+		// Every dot of each shape flips once, so corners and octant
+		// boundaries are lit rather than cancelled
+		p.Rect(b.Bounds())
+		if b.Count() != 24 {
+			t.Errorf("Expected 24 border dots, got %d", b.Count())
+		}
+		p.Circle(3, 3, 3)
+		p.Circle(3, 3, 3)
+		p.Rect(b.Bounds())
+		if b.Count() != 0 {
+			t.Errorf("Expected drawing shapes twice in XOR mode to restore the canvas, got %d dots", b.Count())
+		}
+	})
+
+	t.Run("Clipping", func(t *testing.T) {
+		b := bitmap.New(96, 16)
+		p := gfx.New(b)
+		// Shapes far larger than the canvas only trace the visible part
+		p.Line(0, 0, 1<<28, 0)
+		p.Line(-10, -10, 200, 200)
+		p.Circle(0, 0, 1e7)
+		p.Rect(image.Rect(-1<<28, 15, 1<<28, 1<<28))
+		for x := 0; x < 96; x++ {
+			if !b.Get(x, 0) || !b.Get(x, 15) {
+				t.Fatalf("Expected rows 0 and 15 to be lit at column %d", x)
+			}
+		}
+		for i := 1; i < 15; i++ {
+			if !b.Get(i, i) {
+				t.Errorf("Expected the diagonal to be lit at (%d, %d)", i, i)
+			}
+		}
+		if want := 96*2 + 14; b.Count() != want {
+			t.Errorf("Unexpected dot count. Got %d, want %d", b.Count(), want)
+		}
+
+		allocs := testing.AllocsPerRun(10, func() {
+			p.Line(0, 0, 1<<28, 1<<27)
+		})
+		if allocs > 1 {
+			t.Errorf("Drawing a clipped line allocated %v times", allocs)
+		}
+	})
+
+	t.Run("Gray", func(t *testing.T) {
+		// Painters work on any draw.Image
+		img := image.NewGray(image.Rect(0, 0, 3, 3))
+		gfx.New(img).Line(0, 0, 2, 2)
+		if img.GrayAt(1, 1).Y != 255 || img.GrayAt(1, 0).Y != 0 {
+			t.Error("Expected a diagonal line on the gray image")
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/harperreed/goflipdot/internal/packet"
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+	"github.com/harperreed/goflipdot/pkg/goflipdot"
+	"github.com/harperreed/goflipdot/pkg/imageconv"
+)
+
+var gifPalette = color.Palette{color.Transparent, color.Black, color.White}
+
+// gifFrame returns a frame covering r, lit where lit is true
+func gifFrame(r image.Rectangle, lit ...image.Point) *image.Paletted {
+	img := image.NewPaletted(r, gifPalette)
+	for i := range img.Pix {
+		img.Pix[i] = 1
+	}
+	for _, p := range lit {
+		img.SetColorIndex(p.X, p.Y, 2)
+	}
+	return img
+}
+
+// sentDots decodes every image frame written to port and returns their lit dots
+func sentDots(t *testing.T, port *fakeTransport, height int) [][]image.Point {
+	t.Helper()
+	port.mu.Lock()
+	defer port.mu.Unlock()
+	var frames [][]image.Point
+	for _, b := range port.frames {
+		pkt, err := packet.Decode(b, height)
+		if err != nil {
+			t.Fatalf("Failed to decode frame: %v", err)
+		}
+		img := pkt.(packet.ImagePacket).Image
+		var dots []image.Point
+		for y := 0; y < img.Bounds().Dy(); y++ {
+			for x := 0; x < img.Bounds().Dx(); x++ {
+				if img.GrayAt(x, y).Y >= bitmap.Threshold {
+					dots = append(dots, image.Pt(x, y))
+				}
+			}
+		}
+		frames = append(frames, dots)
+	}
+	return frames
+}
+
+func TestPlayGIF(t *testing.T) {
+	bounds := image.Rect(0, 0, 4, 8)
+	newController := func(t *testing.T, opts ...goflipdot.Option) (*goflipdot.Controller, *fakeTransport) {
+		port := &fakeTransport{t: t}
+		ctrl, err := goflipdot.NewController(port, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		t.Cleanup(func() { ctrl.Close() })
+		if err := ctrl.AddSign("dev", 1, 4, 8, false); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		return ctrl, port
+	}
+
+	t.Run("Disposal", func(t *testing.T) {
+		ctrl, port := newController(t)
+		g := &gif.GIF{
+			Image: []*image.Paletted{
+				gifFrame(bounds, image.Pt(1, 1)),
+				gifFrame(image.Rect(0, 0, 1, 1), image.Pt(0, 0)),
+				gifFrame(image.Rect(3, 7, 4, 8), image.Pt(3, 7)),
+				image.NewPaletted(image.Rect(2, 2, 3, 3), gifPalette),
+			},
+			Delay:     []int{1, 1, 1, 1},
+			Disposal:  []byte{gif.DisposalBackground, gif.DisposalNone, gif.DisposalPrevious, gif.DisposalNone},
+			LoopCount: -1,
+		}
+		if err := ctrl.PlayGIF(context.Background(), g, "dev", imageconv.Options{}); err != nil {
+			t.Fatalf("Failed to play GIF: %v", err)
+		}
+
+		want := [][]image.Point{
+			{image.Pt(1, 1)},
+			// The first frame was cleared to the background
+			{image.Pt(0, 0)},
+			{image.Pt(0, 0), image.Pt(3, 7)},
+			// The third frame was undone
+			{image.Pt(0, 0)},
+		}
+		got := sentDots(t, port, 8)
+		if len(got) != len(want) {
+			t.Fatalf("Unexpected frame count. Got %d (%v), want %d", len(got), got, len(want))
+		}
+		for i := range want {
+			if len(got[i]) != len(want[i]) {
+				t.Errorf("Frame %d: got dots %v, want %v", i, got[i], want[i])
+				continue
+			}
+			for j := range want[i] {
+				if got[i][j] != want[i][j] {
+					t.Errorf("Frame %d: got dots %v, want %v", i, got[i], want[i])
+					break
+				}
+			}
+		}
+	})
+
+	t.Run("LoopAndRefreshLimit", func(t *testing.T) {
+		ctrl, port := newController(t, goflipdot.WithMaxFPS(20))
+		g := &gif.GIF{
+			Image:     []*image.Paletted{gifFrame(bounds, image.Pt(0, 0)), gifFrame(bounds, image.Pt(1, 0))},
+			Delay:     []int{1, 1},
+			LoopCount: 1,
+		}
+		start := time.Now()
+		if err := ctrl.PlayGIF(context.Background(), g, "dev", imageconv.Options{}); err != nil {
+			t.Fatalf("Failed to play GIF: %v", err)
+		}
+		// Two plays of two frames, each held for the 50ms slot rather than
+		// its 10ms delay
+		if got := len(sentDots(t, port, 8)); got != 4 {
+			t.Errorf("Expected 4 frames for a GIF played twice, got %d", got)
+		}
+		if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+			t.Errorf("GIF played in %v, faster than the frame rate limit", elapsed)
+		}
+	})
+
+	t.Run("Forever", func(t *testing.T) {
+		ctrl, _ := newController(t)
+		g := &gif.GIF{
+			Image: []*image.Paletted{gifFrame(bounds, image.Pt(0, 0)), gifFrame(bounds)},
+			Delay: []int{1, 1},
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := ctrl.PlayGIF(ctx, g, "dev", imageconv.Options{}); err != context.DeadlineExceeded {
+			t.Errorf("Expected a looping GIF to play until the deadline, got %v", err)
+		}
+	})
+}
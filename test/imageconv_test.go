@@ -0,0 +1,143 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+	"github.com/harperreed/goflipdot/pkg/imageconv"
+)
+
+// litDots counts the lit dots in img inside r
+func litDots(img *image.Gray, r image.Rectangle) int {
+	n := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.GrayAt(x, y).Y >= bitmap.Threshold {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func uniform(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+// convert runs imageconv.Convert, failing the test on error
+func convert(t *testing.T, src image.Image, width, height int, opts imageconv.Options) *image.Gray {
+	t.Helper()
+	img, err := imageconv.Convert(src, width, height, opts)
+	if err != nil {
+		t.Fatalf("Failed to convert image: %v", err)
+	}
+	return img
+}
+
+func TestImageConv(t *testing.T) {
+	t.Run("Fit", func(t *testing.T) {
+		// A 2:1 white image fits a square sign as a centred band
+		got := convert(t, uniform(20, 10, color.White), 10, 10, imageconv.Options{Scale: imageconv.ScaleFit})
+		band := image.Rect(0, 2, 10, 7)
+		if n := litDots(got, band); n != band.Dx()*band.Dy() {
+			t.Errorf("Expected the band to be lit, got %d of %d dots", n, band.Dx()*band.Dy())
+		}
+		if n := litDots(got, got.Bounds()); n != band.Dx()*band.Dy() {
+			t.Errorf("Expected letterboxing to be blank, got %d lit dots", n)
+		}
+	})
+
+	t.Run("Fill", func(t *testing.T) {
+		// White in the middle third, black on the left and right thirds: fill
+		// crops the sides of a wide image to a square sign
+		src := uniform(30, 10, color.Black)
+		draw.Draw(src, image.Rect(10, 0, 20, 10), image.NewUniform(color.White), image.Point{}, draw.Src)
+		got := convert(t, src, 10, 10, imageconv.Options{Scale: imageconv.ScaleFill})
+		if n := litDots(got, got.Bounds()); n != 100 {
+			t.Errorf("Expected the whole sign to be lit, got %d dots", n)
+		}
+	})
+
+	t.Run("Stretch", func(t *testing.T) {
+		got := convert(t, uniform(20, 10, color.White), 10, 10, imageconv.Options{Scale: imageconv.ScaleStretch})
+		if n := litDots(got, got.Bounds()); n != 100 {
+			t.Errorf("Expected the whole sign to be lit, got %d dots", n)
+		}
+	})
+
+	t.Run("Dither", func(t *testing.T) {
+		gray := uniform(16, 16, color.Gray{Y: 64})
+		cases := map[string]struct {
+			dither   imageconv.Dither
+			min, max int
+		}{
+			"Threshold":      {imageconv.DitherThreshold, 0, 0},
+			"FloydSteinberg": {imageconv.DitherFloydSteinberg, 56, 72},
+			"Atkinson":       {imageconv.DitherAtkinson, 40, 72},
+			"Bayer":          {imageconv.DitherBayer, 64, 64},
+		}
+		for name, c := range cases {
+			got := convert(t, gray, 16, 16, imageconv.Options{Dither: c.dither})
+			if n := litDots(got, got.Bounds()); n < c.min || n > c.max {
+				t.Errorf("%s: a quarter-gray image lit %d of 256 dots, want %d-%d", name, n, c.min, c.max)
+			}
+		}
+
+		got := convert(t, gray, 16, 16, imageconv.Options{Threshold: 50})
+		if n := litDots(got, got.Bounds()); n != 256 {
+			t.Errorf("Expected a low threshold to light every dot, got %d", n)
+		}
+	})
+
+	t.Run("Invert", func(t *testing.T) {
+		got := convert(t, uniform(4, 4, color.Black), 4, 4, imageconv.Options{Invert: true})
+		if n := litDots(got, got.Bounds()); n != 16 {
+			t.Errorf("Expected inverted black to be lit, got %d dots", n)
+		}
+	})
+
+	t.Run("InvertTransparent", func(t *testing.T) {
+		got := convert(t, image.NewRGBA(image.Rect(0, 0, 4, 4)), 4, 4, imageconv.Options{Invert: true})
+		if n := litDots(got, got.Bounds()); n != 0 {
+			t.Errorf("Expected inverted transparency to stay off, got %d dots", n)
+		}
+		// Black fitted to a square sign: only the band it covers is lit
+		got = convert(t, uniform(20, 10, color.Black), 10, 10, imageconv.Options{Scale: imageconv.ScaleFit, Invert: true})
+		if n := litDots(got, got.Bounds()); n != 50 {
+			t.Errorf("Expected letterboxing to stay off when inverted, got %d lit dots", n)
+		}
+	})
+
+	t.Run("InvalidSize", func(t *testing.T) {
+		for _, size := range []image.Point{{0, 4}, {4, -1}} {
+			if _, err := imageconv.Convert(uniform(4, 4, color.White), size.X, size.Y, imageconv.Options{}); !errors.Is(err, imageconv.ErrInvalidSize) {
+				t.Errorf("Expected ErrInvalidSize for %v, got %v", size, err)
+			}
+		}
+	})
+
+	t.Run("Decode", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, uniform(8, 8, color.White)); err != nil {
+			t.Fatalf("Failed to encode PNG: %v", err)
+		}
+		got, err := imageconv.Decode(&buf, 4, 4, imageconv.Options{})
+		if err != nil {
+			t.Fatalf("Failed to decode PNG: %v", err)
+		}
+		if n := litDots(got, got.Bounds()); n != 16 {
+			t.Errorf("Expected a white PNG to light every dot, got %d", n)
+		}
+		if _, err := imageconv.Decode(bytes.NewReader([]byte("not an image")), 4, 4, imageconv.Options{}); err == nil {
+			t.Error("Expected an error decoding garbage")
+		}
+	})
+}
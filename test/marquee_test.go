@@ -0,0 +1,173 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/harperreed/goflipdot/pkg/bitmap"
+	"github.com/harperreed/goflipdot/pkg/emulator"
+	"github.com/harperreed/goflipdot/pkg/font"
+	"github.com/harperreed/goflipdot/pkg/goflipdot"
+	"github.com/harperreed/goflipdot/pkg/marquee"
+)
+
+// litColumns returns the x positions of lit dots in the top row of img
+func litColumns(img *image.Gray) []int {
+	var cols []int
+	for x := 0; x < img.Bounds().Dx(); x++ {
+		if img.GrayAt(x, 0).Y >= bitmap.Threshold {
+			cols = append(cols, x)
+		}
+	}
+	return cols
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMarquee(t *testing.T) {
+	// A 3-wide strip with only its first column lit, on a 4-wide sign
+	strip := image.NewGray(image.Rect(0, 0, 3, 1))
+	strip.SetGray(0, 0, color.Gray{Y: 255})
+
+	t.Run("Once", func(t *testing.T) {
+		m, err := marquee.NewFromImage(strip, 4, 1, marquee.Config{Speed: 2, Mode: marquee.Once})
+		if err != nil {
+			t.Fatalf("Failed to create marquee: %v", err)
+		}
+		var got [][]int
+		for n := 0; ; n++ {
+			img, ok := m.Frame(n)
+			if !ok {
+				break
+			}
+			got = append(got, litColumns(img))
+		}
+		// One column per frame at 2 columns per second and 2 FPS, entering
+		// from the right and ending blank
+		want := [][]int{nil, {3}, {2}, {1}, {0}, nil, nil, nil}
+		if len(got) != len(want) {
+			t.Fatalf("Unexpected frame count. Got %d (%v), want %d", len(got), got, len(want))
+		}
+		for i := range want {
+			if !equalInts(got[i], want[i]) {
+				t.Errorf("Frame %d: got lit columns %v, want %v", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("RefreshLimit", func(t *testing.T) {
+		m, err := marquee.NewFromImage(strip, 4, 1, marquee.Config{Speed: 10, MaxFPS: 4})
+		if err != nil {
+			t.Fatalf("Failed to create marquee: %v", err)
+		}
+		if m.Step() != 3 {
+			t.Errorf("Unexpected step. Got %d, want 3", m.Step())
+		}
+		if m.Interval() != 300*time.Millisecond {
+			t.Errorf("Unexpected interval. Got %v, want 300ms", m.Interval())
+		}
+	})
+
+	t.Run("Loop", func(t *testing.T) {
+		m, err := marquee.NewFromImage(strip, 4, 1, marquee.Config{Speed: 2, Mode: marquee.Loop, Gap: 1})
+		if err != nil {
+			t.Fatalf("Failed to create marquee: %v", err)
+		}
+		// The strip plus gap repeats every 4 columns
+		for n := 0; n < 4; n++ {
+			a, _ := m.Frame(n)
+			b, ok := m.Frame(n + 4)
+			if !ok {
+				t.Fatalf("Loop marquee ended at frame %d", n+4)
+			}
+			if !equalInts(litColumns(a), litColumns(b)) {
+				t.Errorf("Frame %d and %d differ: %v vs %v", n, n+4, litColumns(a), litColumns(b))
+			}
+		}
+	})
+
+	t.Run("PingPong", func(t *testing.T) {
+		m, err := marquee.NewFromImage(strip, 4, 1, marquee.Config{Speed: 2, Mode: marquee.PingPong})
+		if err != nil {
+			t.Fatalf("Failed to create marquee: %v", err)
+		}
+		// The narrow strip bounces across the one column of slack
+		want := [][]int{{0}, {1}, {0}, {1}}
+		for n, w := range want {
+			img, _ := m.Frame(n)
+			if got := litColumns(img); !equalInts(got, w) {
+				t.Errorf("Frame %d: got lit columns %v, want %v", n, got, w)
+			}
+		}
+	})
+
+	t.Run("Vertical", func(t *testing.T) {
+		tall := image.NewGray(image.Rect(0, 0, 1, 2))
+		tall.SetGray(0, 0, color.Gray{Y: 255})
+		m, err := marquee.NewFromImage(tall, 1, 2, marquee.Config{Speed: 2, Direction: marquee.Vertical, Mode: marquee.Once})
+		if err != nil {
+			t.Fatalf("Failed to create marquee: %v", err)
+		}
+		img, _ := m.Frame(1)
+		if img.GrayAt(0, 1).Y == 0 || img.GrayAt(0, 0).Y != 0 {
+			t.Errorf("Expected the strip to enter from the bottom")
+		}
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		_, err := marquee.NewFromImage(strip, 4, 1, marquee.Config{})
+		if !errors.Is(err, marquee.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for zero speed, got %v", err)
+		}
+	})
+
+	t.Run("TextTooTall", func(t *testing.T) {
+		_, err := marquee.New("Hi", 20, 5, marquee.Config{Speed: 2})
+		if !errors.Is(err, font.ErrOverflow) {
+			t.Errorf("Expected ErrOverflow for 7-row text on a 5-row sign, got %v", err)
+		}
+	})
+
+	t.Run("Play", func(t *testing.T) {
+		emu := emulator.New(7)
+		emu.AddSign(1, 20, 7)
+		updates := 0
+		emu.OnUpdate(func(int) { updates++ })
+		ctrl, err := goflipdot.NewController(struct {
+			io.Reader
+			io.Writer
+		}{new(bytes.Buffer), emu})
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		defer ctrl.Close()
+		if err := ctrl.AddSign("dev", 1, 20, 7, false); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+
+		cfg := marquee.Config{Speed: 1000, MaxFPS: 1000, Mode: marquee.Once}
+		if err := marquee.Play(context.Background(), ctrl, "dev", "Hi", cfg); err != nil {
+			t.Fatalf("Play failed: %v", err)
+		}
+		// "Hi" is 7 columns wide on a 20-column sign
+		if want := 7 + 20 + 1; updates != want {
+			t.Errorf("Unexpected update count. Got %d, want %d", updates, want)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harperreed/goflipdot/internal"
+	"github.com/harperreed/goflipdot/pkg/goflipdot"
+	"github.com/harperreed/goflipdot/pkg/metrics"
+)
+
+// scrape returns the collector's metrics as served over HTTP
+func scrape(t *testing.T, c *metrics.Collector) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != metrics.ContentType {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	return rec.Body.String()
+}
+
+func expectMetrics(t *testing.T, body string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	t.Run("Frames", func(t *testing.T) {
+		collector := metrics.New()
+		port := new(bytes.Buffer)
+		ctrl, err := goflipdot.NewController(port, goflipdot.WithMetrics(collector))
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		defer ctrl.Close()
+		if err := ctrl.AddSign("front", 1, 86, 7, false); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		img, _ := ctrl.CreateImage("front")
+		for i := 0; i < 3; i++ {
+			img.Pix[i] = 0xFF
+			if err := ctrl.DrawImage(img, "front"); err != nil {
+				t.Fatalf("Failed to draw image: %v", err)
+			}
+		}
+		// Unchanged frames are not sent, so they are not counted
+		if err := ctrl.DrawImage(img, "front"); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+
+		expectMetrics(t, scrape(t, collector),
+			"# TYPE flipdot_frames_sent_total counter",
+			`flipdot_frames_sent_total{sign="front"} 3`,
+			fmt.Sprintf("flipdot_bytes_written_total %d", port.Len()),
+			"# TYPE flipdot_write_duration_seconds histogram",
+			`flipdot_write_duration_seconds_bucket{le="+Inf"} 3`,
+			"flipdot_write_duration_seconds_count 3",
+			"flipdot_read_timeouts_total 0",
+			"flipdot_checksum_failures_total 0",
+		)
+	})
+
+	t.Run("DefaultSign", func(t *testing.T) {
+		collector := metrics.New()
+		ctrl, err := goflipdot.NewController(new(bytes.Buffer), goflipdot.WithMetrics(collector))
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		defer ctrl.Close()
+		if err := ctrl.AddSign("front", 1, 86, 7, false); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		img, _ := ctrl.CreateImage("front")
+		// With a single sign, "" selects it and the frame is counted under its name
+		if err := ctrl.DrawImage(img, ""); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+		body := scrape(t, collector)
+		expectMetrics(t, body, `flipdot_frames_sent_total{sign="front"} 1`)
+		if strings.Contains(body, `sign=""`) {
+			t.Errorf("Did not expect a series for the empty sign name:\n%s", body)
+		}
+	})
+
+	t.Run("DroppedFrames", func(t *testing.T) {
+		collector := metrics.New()
+		ctrl, err := goflipdot.NewController(new(bytes.Buffer), goflipdot.WithMetrics(collector), goflipdot.WithMaxFPS(10))
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		defer ctrl.Close()
+		if err := ctrl.AddSign("front", 1, 86, 7, false); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		frames := make([]*image.Gray, 3)
+		for i := range frames {
+			frames[i], _ = ctrl.CreateImage("front")
+			frames[i].Pix[i] = 0xFF
+		}
+		if err := ctrl.DrawImage(frames[0], "front"); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+		// The second frame waits for the next slot and is replaced by the third
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctrl.DrawImage(frames[1], "front")
+		}()
+		time.Sleep(20 * time.Millisecond)
+		if err := ctrl.DrawImage(frames[2], "front"); err != nil {
+			t.Fatalf("Failed to draw image: %v", err)
+		}
+		wg.Wait()
+
+		expectMetrics(t, scrape(t, collector),
+			`flipdot_frames_sent_total{sign="front"} 2`,
+			`flipdot_frames_dropped_total{sign="front"} 1`,
+		)
+	})
+
+	t.Run("Responses", func(t *testing.T) {
+		echo := internal.FormatPacket(internal.CommandStartTest, '0', nil)
+		// A well-formed frame whose checksum digits do not match
+		corrupt := append([]byte(nil), echo...)
+		if corrupt[len(corrupt)-1] == '0' {
+			corrupt[len(corrupt)-1] = '1'
+		} else {
+			corrupt[len(corrupt)-1] = '0'
+		}
+
+		collector := metrics.New()
+		port := newReplyTransport([][]byte{corrupt}, nil, [][]byte{echo})
+		ctrl, err := goflipdot.NewController(port,
+			goflipdot.WithResponseTimeout(50*time.Millisecond),
+			goflipdot.WithMetrics(collector),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		defer ctrl.Close()
+		for i := 0; i < 3; i++ {
+			if _, err := ctrl.StartTestSignsResponse(context.Background()); err != nil {
+				t.Fatalf("Failed to start test: %v", err)
+			}
+		}
+
+		expectMetrics(t, scrape(t, collector),
+			"flipdot_read_timeouts_total 1",
+			"flipdot_checksum_failures_total 1",
+		)
+	})
+
+	t.Run("SlowScrape", func(t *testing.T) {
+		collector := metrics.New()
+		r, w := io.Pipe()
+		defer r.Close()
+		// Nothing reads the pipe yet, so WriteTo blocks on its first write
+		go collector.WriteTo(w)
+		time.Sleep(20 * time.Millisecond)
+
+		counted := make(chan struct{})
+		go func() {
+			collector.FrameSent("front")
+			close(counted)
+		}()
+		select {
+		case <-counted:
+		case <-time.After(time.Second):
+			t.Fatal("Expected counting not to wait for a blocked scrape")
+		}
+		expectMetrics(t, scrape(t, collector), `flipdot_frames_sent_total{sign="front"} 1`)
+	})
+
+	t.Run("Format", func(t *testing.T) {
+		collector := metrics.NewWithBuckets([]float64{0.5, 0.1})
+		collector.FrameSent("b")
+		collector.FrameSent(`a"\`)
+		collector.Write(10, 200*time.Millisecond)
+		collector.Write(5, time.Second)
+
+		var buf bytes.Buffer
+		n, err := collector.WriteTo(&buf)
+		if err != nil || n != int64(buf.Len()) {
+			t.Fatalf("WriteTo returned %d, %v for %d bytes", n, err, buf.Len())
+		}
+		body := buf.String()
+		expectMetrics(t, body,
+			`flipdot_frames_sent_total{sign="a\"\\"} 1`,
+			`flipdot_frames_sent_total{sign="b"} 1`,
+			"flipdot_bytes_written_total 15",
+			`flipdot_write_duration_seconds_bucket{le="0.1"} 0`,
+			`flipdot_write_duration_seconds_bucket{le="0.5"} 1`,
+			`flipdot_write_duration_seconds_bucket{le="+Inf"} 2`,
+			"flipdot_write_duration_seconds_sum 1.2",
+			"flipdot_write_duration_seconds_count 2",
+		)
+		if strings.Index(body, `sign="a`) > strings.Index(body, `sign="b"`) {
+			t.Errorf("Expected series sorted by sign:\n%s", body)
+		}
+	})
+}
@@ -2,8 +2,10 @@ package test
 
 import (
 	"bytes"
+	"errors"
 	"image"
 	"image/color"
+	"io"
 	"testing"
 
 	"github.com/harperreed/goflipdot/internal/packet"
@@ -67,4 +69,123 @@ func TestPackets(t *testing.T) {
 			t.Errorf("Unexpected ImagePacket end byte. Got %v", gotBytes[len(gotBytes)-3])
 		}
 	})
+
+	t.Run("ImagePacketAddresses", func(t *testing.T) {
+		img := image.NewGray(image.Rect(0, 0, 2, 8))
+		img.Set(0, 0, color.White)
+
+		golden := map[int][]byte{
+			10: {0x02, '1', 'A', '0', '2', '8', '0', '0', '0', 0x03, '6', '1'},
+			15: {0x02, '1', 'F', '0', '2', '8', '0', '0', '0', 0x03, '5', 'C'},
+		}
+		for address, expected := range golden {
+			gotBytes, err := packet.ImagePacket{Address: address, Image: img}.GetBytes()
+			if err != nil {
+				t.Fatalf("Failed to get bytes for address %d: %v", address, err)
+			}
+			if !bytes.Equal(gotBytes, expected) {
+				t.Errorf("Unexpected ImagePacket bytes for address %d. Got %q, want %q", address, gotBytes, expected)
+			}
+			pkt, err := packet.Decode(gotBytes, 8)
+			if err != nil {
+				t.Fatalf("Failed to decode address %d: %v", address, err)
+			}
+			if got := pkt.(packet.ImagePacket).Address; got != address {
+				t.Errorf("Unexpected decoded address. Got %d, want %d", got, address)
+			}
+		}
+
+		for _, address := range []int{-1, packet.MaxAddress + 1} {
+			_, err := packet.ImagePacket{Address: address, Image: img}.GetBytes()
+			if !errors.Is(err, packet.ErrInvalidAddress) {
+				t.Errorf("Expected ErrInvalidAddress for address %d, got %v", address, err)
+			}
+		}
+	})
+
+	t.Run("ImagePacketResolution", func(t *testing.T) {
+		sizes := []struct {
+			width, height int
+			resolution    string
+		}{
+			{86, 7, "56"},
+			{96, 16, "C0"},
+			{28, 19, "54"},
+			{85, 24, "FF"},
+		}
+		for _, size := range sizes {
+			img := image.NewGray(image.Rect(0, 0, size.width, size.height))
+			gotBytes, err := packet.ImagePacket{Address: 1, Image: img}.GetBytes()
+			if err != nil {
+				t.Fatalf("Failed to get bytes for %dx%d: %v", size.width, size.height, err)
+			}
+			if got := string(gotBytes[3:5]); got != size.resolution {
+				t.Errorf("Unexpected resolution for %dx%d. Got %s, want %s", size.width, size.height, got, size.resolution)
+			}
+		}
+
+		img := image.NewGray(image.Rect(0, 0, 112, 20))
+		if _, err := (packet.ImagePacket{Address: 1, Image: img}).GetBytes(); !errors.Is(err, packet.ErrImageTooLarge) {
+			t.Errorf("Expected ErrImageTooLarge for 112x20, got %v", err)
+		}
+	})
+
+	t.Run("Decode", func(t *testing.T) {
+		img := image.NewGray(image.Rect(0, 0, 96, 16))
+		img.Set(0, 0, color.White)
+		img.Set(50, 8, color.White)
+		img.Set(95, 15, color.White)
+
+		b, err := packet.ImagePacket{Address: 2, Image: img}.GetBytes()
+		if err != nil {
+			t.Fatalf("Failed to get bytes: %v", err)
+		}
+		pkt, err := packet.Decode(b, 16)
+		if err != nil {
+			t.Fatalf("Failed to decode: %v", err)
+		}
+		imgPkt, ok := pkt.(packet.ImagePacket)
+		if !ok {
+			t.Fatalf("Unexpected packet type %T", pkt)
+		}
+		if imgPkt.Address != 2 {
+			t.Errorf("Unexpected address. Got %d, want 2", imgPkt.Address)
+		}
+		if !bytes.Equal(imgPkt.Image.Pix, img.Pix) {
+			t.Error("Decoded image does not match encoded image")
+		}
+
+		b[len(b)-1] = '0'
+		if _, err := packet.Decode(b, 16); !errors.Is(err, packet.ErrChecksum) {
+			t.Errorf("Expected checksum error, got %v", err)
+		}
+	})
+
+	t.Run("Scanner", func(t *testing.T) {
+		start, _ := packet.TestSignsStartPacket{}.GetBytes()
+		stop, _ := packet.TestSignsStopPacket{}.GetBytes()
+		corrupt := append([]byte(nil), start...)
+		corrupt[len(corrupt)-2] = 'F'
+
+		var stream []byte
+		stream = append(stream, 0x00, 'x')
+		stream = append(stream, start...)
+		stream = append(stream, corrupt...)
+		stream = append(stream, stop...)
+		stream = append(stream, 0x02, '1')
+
+		s := packet.NewScanner(bytes.NewReader(stream), 7)
+		if pkt, err := s.Next(); err != nil || pkt != (packet.TestSignsStartPacket{}) {
+			t.Errorf("Expected start packet, got %v, %v", pkt, err)
+		}
+		if _, err := s.Next(); !errors.Is(err, packet.ErrChecksum) {
+			t.Errorf("Expected checksum error, got %v", err)
+		}
+		if pkt, err := s.Next(); err != nil || pkt != (packet.TestSignsStopPacket{}) {
+			t.Errorf("Expected stop packet, got %v, %v", pkt, err)
+		}
+		if _, err := s.Next(); err != io.EOF {
+			t.Errorf("Expected EOF for truncated frame, got %v", err)
+		}
+	})
 }
@@ -0,0 +1,31 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/harperreed/goflipdot/internal"
+)
+
+func TestProtocol(t *testing.T) {
+	t.Run("FormatPacket", func(t *testing.T) {
+		got := internal.FormatPacket(internal.CommandCodes["start_test_signs"], '0', nil)
+		expected := []byte{0x02, '3', '0', 0x03, '9', 'A'}
+		if !bytes.Equal(got, expected) {
+			t.Errorf("Unexpected start test packet. Got %v, want %v", got, expected)
+		}
+
+		got = internal.FormatPacket(internal.CommandCodes["write_image"], 'A', []byte("028000"))
+		expected = []byte{0x02, '1', 'A', '0', '2', '8', '0', '0', '0', 0x03, '6', '1'}
+		if !bytes.Equal(got, expected) {
+			t.Errorf("Unexpected image packet. Got %q, want %q", got, expected)
+		}
+	})
+
+	t.Run("ToAsciiHex", func(t *testing.T) {
+		got := internal.ToAsciiHex([]byte{0x00, 0xAB, 0x5f})
+		if string(got) != "00AB5F" {
+			t.Errorf("Unexpected ASCII hex. Got %s, want 00AB5F", got)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/harperreed/goflipdot/internal"
+	"github.com/harperreed/goflipdot/pkg/goflipdot"
+)
+
+// replyTransport answers each write with the next scripted reply, delivered
+// in the given chunks
+type replyTransport struct {
+	replies [][][]byte
+	reads   chan []byte
+	closed  chan struct{}
+}
+
+func newReplyTransport(replies ...[][]byte) *replyTransport {
+	return &replyTransport{
+		replies: replies,
+		reads:   make(chan []byte, 16),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (r *replyTransport) Write(p []byte) (int, error) {
+	if len(r.replies) > 0 {
+		for _, chunk := range r.replies[0] {
+			r.reads <- chunk
+		}
+		r.replies = r.replies[1:]
+	}
+	return len(p), nil
+}
+
+func (r *replyTransport) Read(p []byte) (int, error) {
+	select {
+	case chunk := <-r.reads:
+		return copy(p, chunk), nil
+	case <-r.closed:
+		return 0, io.EOF
+	}
+}
+
+func (r *replyTransport) Close() error {
+	close(r.closed)
+	return nil
+}
+
+func TestControllerResponses(t *testing.T) {
+	echo := internal.FormatPacket(internal.CommandStartTest, '0', nil)
+	corrupt := append([]byte(nil), echo...)
+	corrupt[len(corrupt)-1] ^= 1
+
+	cases := []struct {
+		name     string
+		reply    [][]byte
+		policy   goflipdot.ResponsePolicy
+		wantKind goflipdot.ResponseKind
+		wantErr  error
+	}{
+		{"Ack", [][]byte{{0x06}}, goflipdot.ResponsePolicy{}, goflipdot.ResponseAck, nil},
+		{"Echo", [][]byte{echo}, goflipdot.ResponsePolicy{}, goflipdot.ResponseAck, nil},
+		{"SplitEcho", [][]byte{{0x00}, echo[:3], echo[3:]}, goflipdot.ResponsePolicy{}, goflipdot.ResponseAck, nil},
+		{"Nak", [][]byte{{0x15}}, goflipdot.ResponsePolicy{}, goflipdot.ResponseError, goflipdot.ErrRejected},
+		{"Malformed", [][]byte{corrupt}, goflipdot.ResponsePolicy{}, goflipdot.ResponseUnknown, nil},
+		{"RejectMalformed", [][]byte{corrupt}, goflipdot.ResponsePolicy{RejectMalformed: true}, goflipdot.ResponseUnknown, goflipdot.ErrMalformedResponse},
+		{"Missing", nil, goflipdot.ResponsePolicy{}, goflipdot.ResponseNone, nil},
+		{"RequireResponse", nil, goflipdot.ResponsePolicy{RequireResponse: true}, goflipdot.ResponseNone, goflipdot.ErrNoResponse},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			port := newReplyTransport(c.reply)
+			ctrl, err := goflipdot.NewController(port,
+				goflipdot.WithResponseTimeout(50*time.Millisecond),
+				goflipdot.WithResponsePolicy(c.policy),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create controller: %v", err)
+			}
+			defer ctrl.Close()
+
+			resp, err := ctrl.StartTestSignsResponse(context.Background())
+			if !errors.Is(err, c.wantErr) {
+				t.Errorf("Unexpected error. Got %v, want %v", err, c.wantErr)
+			}
+			if resp.Kind != c.wantKind {
+				t.Errorf("Unexpected response kind. Got %v, want %v", resp.Kind, c.wantKind)
+			}
+			if c.name == "Echo" && resp.Command != internal.CommandStartTest {
+				t.Errorf("Unexpected response command %q", resp.Command)
+			}
+		})
+	}
+	t.Run("Close", func(t *testing.T) {
+		ctrl, err := goflipdot.NewController(newReplyTransport(), goflipdot.WithResponseTimeout(time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		errs := make(chan error, 1)
+		go func() {
+			_, err := ctrl.StartTestSignsResponse(context.Background())
+			errs <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		start := time.Now()
+		if err := ctrl.Close(); err != nil {
+			t.Errorf("Failed to close controller: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Close waited %v for a response", elapsed)
+		}
+		if err := <-errs; !errors.Is(err, goflipdot.ErrClosed) {
+			t.Errorf("Expected ErrClosed for the waiting command, got %v", err)
+		}
+	})
+}
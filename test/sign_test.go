@@ -1,10 +1,12 @@
 package test
 
 import (
+	"errors"
 	"image"
 	"image/color"
 	"testing"
 
+	"github.com/harperreed/goflipdot/internal/packet"
 	"github.com/harperreed/goflipdot/internal/sign"
 )
 
@@ -33,6 +35,19 @@ func TestSign(t *testing.T) {
 		}
 	})
 
+	t.Run("InvalidAddress", func(t *testing.T) {
+		if _, err := sign.NewHanoverSign(packet.MaxAddress+1, 86, 7, false); !errors.Is(err, packet.ErrInvalidAddress) {
+			t.Errorf("Expected ErrInvalidAddress, got %v", err)
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		// 112 columns of 3 bytes overflow the one-byte resolution field
+		if _, err := sign.NewHanoverSign(1, 112, 19, false); !errors.Is(err, packet.ErrImageTooLarge) {
+			t.Errorf("Expected ErrImageTooLarge, got %v", err)
+		}
+	})
+
 	t.Run("FlipImage", func(t *testing.T) {
 		s, err := sign.NewHanoverSign(1, 86, 7, false)
 		if err != nil {
@@ -56,4 +71,87 @@ func TestSign(t *testing.T) {
 			t.Error("Bottom-left pixel should be black after flipping")
 		}
 	})
+
+	t.Run("OrientImage", func(t *testing.T) {
+		s, err := sign.NewHanoverSign(1, 4, 2, false)
+		if err != nil {
+			t.Fatalf("Failed to create sign: %v", err)
+		}
+
+		cases := []struct {
+			name        string
+			orientation sign.Orientation
+			flip        bool
+			want        image.Point
+		}{
+			{"None", sign.Orientation{}, false, image.Pt(1, 0)},
+			{"Flip", sign.Orientation{}, true, image.Pt(2, 1)},
+			{"MirrorHorizontal", sign.Orientation{MirrorHorizontal: true}, false, image.Pt(2, 0)},
+			{"MirrorVertical", sign.Orientation{MirrorVertical: true}, false, image.Pt(1, 1)},
+			{"Rotate90", sign.Orientation{Rotation: sign.Rotate90}, false, image.Pt(3, 1)},
+			{"Rotate270", sign.Orientation{Rotation: sign.Rotate270}, false, image.Pt(0, 0)},
+			{"Rotate270Flip", sign.Orientation{Rotation: sign.Rotate270}, true, image.Pt(3, 1)},
+		}
+		for _, tc := range cases {
+			s.Orientation = tc.orientation
+			s.Flip = tc.flip
+
+			img := s.CreateImage()
+			if err := s.ValidateImage(img); err != nil {
+				t.Fatalf("%s: created image is not valid: %v", tc.name, err)
+			}
+			img.Set(1, 0, color.White)
+
+			out := s.OrientImage(img)
+			if out.Bounds().Dx() != 4 || out.Bounds().Dy() != 2 {
+				t.Fatalf("%s: unexpected oriented size %v", tc.name, out.Bounds())
+			}
+			if out.GrayAt(tc.want.X, tc.want.Y).Y != 255 {
+				t.Errorf("%s: expected dot at %v", tc.name, tc.want)
+			}
+		}
+
+		s.Orientation = sign.Orientation{Rotation: 45}
+		if err := s.ValidateImage(s.CreateImage()); !errors.Is(err, sign.ErrInvalidOrientation) {
+			t.Errorf("Expected ErrInvalidOrientation, got %v", err)
+		}
+	})
+}
+
+func TestSignModels(t *testing.T) {
+	t.Run("Catalog", func(t *testing.T) {
+		m, err := sign.LookupModel("7000-28x19")
+		if err != nil {
+			t.Fatalf("Failed to look up model: %v", err)
+		}
+		if m.Columns != 28 || m.Rows != 19 || m.ColumnBytes() != 3 || m.RefreshRate <= 0 {
+			t.Errorf("Unexpected model: %+v", m)
+		}
+		for _, m := range sign.Models() {
+			if err := m.Validate(); err != nil {
+				t.Errorf("Catalog model %s is invalid: %v", m.Name, err)
+			}
+		}
+		if _, err := sign.LookupModel("9999-1x1"); !errors.Is(err, sign.ErrUnknownModel) {
+			t.Errorf("Expected ErrUnknownModel, got %v", err)
+		}
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		if err := sign.RegisterModel(sign.Model{Name: "test-112x19", Columns: 112, Rows: 19}); !errors.Is(err, packet.ErrImageTooLarge) {
+			t.Errorf("Expected ErrImageTooLarge for an unencodable model, got %v", err)
+		}
+		if err := sign.RegisterModel(sign.Model{Name: "test-10x10", Columns: 10, Rows: 10, RefreshRate: 5}); err != nil {
+			t.Fatalf("Failed to register model: %v", err)
+		}
+		if m, err := sign.LookupModel("test-10x10"); err != nil || m.ColumnBytes() != 2 || m.RefreshRate != 5 {
+			t.Errorf("Unexpected registered model %+v: %v", m, err)
+		}
+		if err := sign.RegisterModel(sign.Model{Name: "test-20x7", Columns: 20, Rows: 7}); err != nil {
+			t.Fatalf("Failed to register model: %v", err)
+		}
+		if m, _ := sign.LookupModel("test-20x7"); m.RefreshRate != sign.DefaultRefreshRate {
+			t.Errorf("Expected a model without a refresh rate to get the default, got %v", m.RefreshRate)
+		}
+	})
 }
@@ -0,0 +1,313 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harperreed/goflipdot/internal/controller"
+	"github.com/harperreed/goflipdot/internal/packet"
+	"github.com/harperreed/goflipdot/pkg/goflipdot"
+	"github.com/harperreed/goflipdot/pkg/metrics"
+)
+
+// writePackets decodes the image packets in one write to the bus
+func writePackets(t *testing.T, b []byte, height int) []packet.ImagePacket {
+	t.Helper()
+	var pkts []packet.ImagePacket
+	s := packet.NewScanner(bytes.NewReader(b), height)
+	for {
+		pkt, err := s.Next()
+		if err == io.EOF {
+			return pkts
+		}
+		if err != nil {
+			t.Fatalf("Failed to decode packet: %v", err)
+		}
+		pkts = append(pkts, pkt.(packet.ImagePacket))
+	}
+}
+
+func TestVirtualDisplay(t *testing.T) {
+	port := &fakeTransport{t: t}
+	ctrl, err := goflipdot.NewController(port)
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	defer ctrl.Close()
+
+	// Four 28x19 panels side by side, the last mounted upside down
+	names := []string{"p1", "p2", "p3", "p4"}
+	var tiles []goflipdot.Tile
+	for i, name := range names {
+		if err := ctrl.AddSign(name, i+1, 28, 19, i == 3); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		tiles = append(tiles, goflipdot.Tile{Sign: name, Offset: image.Pt(i*28, 0)})
+	}
+	board, err := ctrl.AddVirtualDisplay("board", 112, 19, tiles...)
+	if err != nil {
+		t.Fatalf("Failed to add virtual display: %v", err)
+	}
+	writes := func() [][]byte {
+		port.mu.Lock()
+		defer port.mu.Unlock()
+		return append([][]byte(nil), port.frames...)
+	}
+
+	img := board.CreateImage()
+	for i := range names {
+		img.SetGray(i*28+i, 0, color.Gray{Y: 255})
+	}
+
+	t.Run("Split", func(t *testing.T) {
+		if err := board.Draw(img); err != nil {
+			t.Fatalf("Failed to draw: %v", err)
+		}
+		w := writes()
+		if len(w) != 1 {
+			t.Fatalf("Expected one back-to-back write, got %d", len(w))
+		}
+		pkts := writePackets(t, w[0], 19)
+		if len(pkts) != 4 {
+			t.Fatalf("Expected 4 packets, got %d", len(pkts))
+		}
+		for i, pkt := range pkts {
+			if pkt.Address != i+1 {
+				t.Errorf("Packet %d has address %d, want %d", i, pkt.Address, i+1)
+			}
+			x, y := i, 0
+			if i == 3 {
+				// The flipped panel receives its part rotated 180 degrees
+				x, y = 27-i, 18
+			}
+			if pkt.Image.GrayAt(x, y).Y == 0 {
+				t.Errorf("Panel %d is missing its dot at (%d,%d)", i+1, x, y)
+			}
+		}
+	})
+
+	t.Run("OnlyChanged", func(t *testing.T) {
+		img.SetGray(30, 5, color.Gray{Y: 255})
+		if err := board.Draw(img); err != nil {
+			t.Fatalf("Failed to draw: %v", err)
+		}
+		w := writes()
+		pkts := writePackets(t, w[len(w)-1], 19)
+		if len(pkts) != 1 || pkts[0].Address != 2 {
+			t.Errorf("Expected only panel 2 to be resent, got %d packets", len(pkts))
+		}
+
+		if err := board.Draw(img, goflipdot.Force()); err != nil {
+			t.Fatalf("Failed to force draw: %v", err)
+		}
+		w = writes()
+		if got := len(writePackets(t, w[len(w)-1], 19)); got != 4 {
+			t.Errorf("Expected Force to resend every panel, got %d packets", got)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ctrl.AddVirtualDisplay("wide", 50, 19, goflipdot.Tile{Sign: "p1", Offset: image.Pt(30, 0)})
+		if !errors.Is(err, controller.ErrInvalidTile) {
+			t.Errorf("Expected ErrInvalidTile for a tile off the canvas, got %v", err)
+		}
+		_, err = ctrl.AddVirtualDisplay("twice", 56, 19, goflipdot.Tile{Sign: "p1"}, goflipdot.Tile{Sign: "p1", Offset: image.Pt(28, 0)})
+		if !errors.Is(err, controller.ErrInvalidTile) {
+			t.Errorf("Expected ErrInvalidTile for a sign placed twice, got %v", err)
+		}
+		_, err = ctrl.AddVirtualDisplay("overlap", 56, 19, goflipdot.Tile{Sign: "p1"}, goflipdot.Tile{Sign: "p2", Offset: image.Pt(20, 0)})
+		if !errors.Is(err, controller.ErrInvalidTile) {
+			t.Errorf("Expected ErrInvalidTile for overlapping tiles, got %v", err)
+		}
+		if err := board.Draw(image.NewGray(image.Rect(0, 0, 28, 19))); err == nil {
+			t.Error("Expected an error drawing an image the wrong size")
+		}
+		if got, err := ctrl.VirtualDisplay("board"); err != nil || got != board {
+			t.Errorf("Expected to look up the board, got %v", err)
+		}
+	})
+
+	t.Run("Reoriented", func(t *testing.T) {
+		// Turned on its side, p1 is 19 wide and 28 tall and overruns the board
+		if err := ctrl.SetOrientation("p1", goflipdot.Orientation{Rotation: goflipdot.Rotate90}); err != nil {
+			t.Fatalf("Failed to set orientation: %v", err)
+		}
+		if err := board.Draw(img); !errors.Is(err, controller.ErrInvalidTile) {
+			t.Errorf("Expected ErrInvalidTile for a sign that no longer fits, got %v", err)
+		}
+		if err := ctrl.SetOrientation("p1", goflipdot.Orientation{}); err != nil {
+			t.Fatalf("Failed to set orientation: %v", err)
+		}
+		if err := board.Draw(img); err != nil {
+			t.Errorf("Expected the board to draw once p1 fits again, got %v", err)
+		}
+	})
+}
+
+func TestVirtualDisplayFrameRate(t *testing.T) {
+	port := &fakeTransport{t: t}
+	ctrl, err := goflipdot.NewController(port)
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	defer ctrl.Close()
+
+	// Rate limits come from the signs, the slowest of which paces the display
+	for i, name := range []string{"left", "right"} {
+		if err := ctrl.AddSignModel(name, i+1, "7000-28x19"); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+	}
+	ctrl.SetMaxFPS("left", 10)
+	ctrl.SetMaxFPS("right", 5)
+	board, err := ctrl.AddVirtualDisplay("board", 56, 19,
+		goflipdot.Tile{Sign: "left"},
+		goflipdot.Tile{Sign: "right", Offset: image.Pt(28, 0)},
+	)
+	if err != nil {
+		t.Fatalf("Failed to add virtual display: %v", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		img := board.CreateImage()
+		img.Pix[i] = 0xFF
+		img.Pix[28+i] = 0xFF
+		if err := board.Draw(img); err != nil {
+			t.Fatalf("Failed to draw frame %d: %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 380*time.Millisecond {
+		t.Errorf("Three frames at 5 fps were sent in %v", elapsed)
+	}
+
+	// A frame drawn straight to a sign waits for the slot the display used
+	start = time.Now()
+	img, _ := ctrl.CreateImage("left")
+	if err := ctrl.DrawImage(img, "left"); err != nil {
+		t.Fatalf("Failed to draw image: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Sign was drawn %v after a display frame, inside its 100ms slot", elapsed)
+	}
+
+	port.mu.Lock()
+	defer port.mu.Unlock()
+	if len(port.frames) != 4 {
+		t.Errorf("Expected 4 writes, got %d", len(port.frames))
+	}
+}
+
+func TestVirtualDisplaySupersedes(t *testing.T) {
+	port := &fakeTransport{t: t}
+	ctrl, err := goflipdot.NewController(port)
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	defer ctrl.Close()
+	if err := ctrl.AddSign("dev", 1, 8, 8, false); err != nil {
+		t.Fatalf("Failed to add sign: %v", err)
+	}
+	board, err := ctrl.AddVirtualDisplay("board", 8, 8, goflipdot.Tile{Sign: "dev"})
+	if err != nil {
+		t.Fatalf("Failed to add virtual display: %v", err)
+	}
+	ctrl.SetMaxFPS("dev", 5)
+
+	frame := func(i int) *image.Gray {
+		img := board.CreateImage()
+		img.Pix[i] = 0xFF
+		return img
+	}
+	if err := ctrl.DrawImage(frame(0), "dev"); err != nil {
+		t.Fatalf("Failed to draw image: %v", err)
+	}
+	// The next direct frame waits for the sign's slot
+	done := make(chan error, 1)
+	go func() {
+		done <- ctrl.DrawImage(frame(1), "dev")
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// Lifting the limit lets the display draw at once, while the direct frame
+	// is still waiting on its timer
+	ctrl.SetMaxFPS("dev", 0)
+	if err := board.Draw(frame(2)); err != nil {
+		t.Fatalf("Failed to draw: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Superseded draw failed: %v", err)
+	}
+
+	port.mu.Lock()
+	last := port.frames[len(port.frames)-1]
+	port.mu.Unlock()
+	if pkts := writePackets(t, last, 8); len(pkts) != 1 || pkts[0].Image.Pix[2] == 0 {
+		t.Errorf("Expected the display frame to be the last one sent")
+	}
+	if n, _ := ctrl.DroppedFrames("dev"); n != 1 {
+		t.Errorf("Expected the superseded frame to be counted as dropped, got %d", n)
+	}
+}
+
+func TestVirtualDisplayDropped(t *testing.T) {
+	collector := metrics.New()
+	ctrl, err := goflipdot.NewController(new(bytes.Buffer), goflipdot.WithMetrics(collector))
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	defer ctrl.Close()
+	for i, name := range []string{"left", "right"} {
+		if err := ctrl.AddSign(name, i+1, 8, 8, false); err != nil {
+			t.Fatalf("Failed to add sign: %v", err)
+		}
+		ctrl.SetMaxFPS(name, 10)
+	}
+	board, err := ctrl.AddVirtualDisplay("board", 16, 8,
+		goflipdot.Tile{Sign: "left"},
+		goflipdot.Tile{Sign: "right", Offset: image.Pt(8, 0)},
+	)
+	if err != nil {
+		t.Fatalf("Failed to add virtual display: %v", err)
+	}
+
+	// Only the left half changes after the first frame
+	frame := func(i int) *image.Gray {
+		img := board.CreateImage()
+		img.Pix[i] = 0xFF
+		img.Pix[8] = 0xFF
+		return img
+	}
+	if err := board.Draw(frame(0)); err != nil {
+		t.Fatalf("Failed to draw: %v", err)
+	}
+	// The second frame waits for the next slot and is replaced by the third
+	done := make(chan error, 1)
+	go func() {
+		done <- board.Draw(frame(1))
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if err := board.Draw(frame(2)); err != nil {
+		t.Fatalf("Failed to draw: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Superseded draw failed: %v", err)
+	}
+
+	for name, want := range map[string]uint64{"left": 1, "right": 0} {
+		if n, _ := ctrl.DroppedFrames(name); n != want {
+			t.Errorf("Unexpected dropped frames for %s. Got %d, want %d", name, n, want)
+		}
+	}
+	body := scrape(t, collector)
+	expectMetrics(t, body, `flipdot_frames_dropped_total{sign="left"} 1`)
+	if strings.Contains(body, `flipdot_frames_dropped_total{sign="right"}`) {
+		t.Errorf("Did not expect a dropped frame for the unchanged sign:\n%s", body)
+	}
+}